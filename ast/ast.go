@@ -2,13 +2,18 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
 	"monkey-lang/token"
+	"strconv"
+	"strings"
 )
 
 type (
 	Node interface {
 		TokenLiteral() string
 		String() string
+		Pos() token.Position
+		End() token.Position
 	}
 	Statement interface {
 		Node
@@ -39,14 +44,64 @@ type (
 		ReturnValue Expression
 	}
 
+	ThrowStatement struct {
+		Token token.Token
+		Value Expression
+	}
+
+	WhileStatement struct {
+		Token     token.Token
+		Condition Expression
+		Body      *BlockStatement
+	}
+
+	// ForStatement binds Variable to each item of Iterable in turn.
+	ForStatement struct {
+		Token    token.Token
+		Variable *Identifier
+		Iterable Expression
+		Body     *BlockStatement
+	}
+
+	BreakStatement struct {
+		Token token.Token
+	}
+
+	ContinueStatement struct {
+		Token token.Token
+	}
+
+	// TryStatement has a Catch block, a Finally block or both. CatchParam is
+	// bound to the caught error inside Catch.
+	TryStatement struct {
+		Token      token.Token
+		Body       *BlockStatement
+		CatchParam *Identifier
+		Catch      *BlockStatement
+		Finally    *BlockStatement
+	}
+
 	ExpressionStatement struct {
 		Token      token.Token
 		Expression Expression
 	}
 
+	// IntegerLiteral holds literals past the int64 range in Big, with Value
+	// left at zero; whether those are allowed is up to the evaluator.
 	IntegerLiteral struct {
 		Token token.Token
 		Value int64
+		Big   *big.Int
+	}
+
+	FloatLiteral struct {
+		Token token.Token
+		Value float64
+	}
+
+	StringLiteral struct {
+		Token token.Token
+		Value string
 	}
 
 	PrefixExpression struct {
@@ -62,6 +117,24 @@ type (
 		Right    Expression
 	}
 
+	// AssignExpression is `Target Operator Value` where Operator is = or a
+	// compound form such as +=. Target is an *Identifier or *IndexExpression.
+	AssignExpression struct {
+		Token    token.Token
+		Target   Expression
+		Operator string
+		Value    Expression
+	}
+
+	// LogicalExpression is kept apart from InfixExpression because its right
+	// operand is only evaluated when the left one does not decide the result.
+	LogicalExpression struct {
+		Token    token.Token
+		Left     Expression
+		Operator string
+		Right    Expression
+	}
+
 	Boolean struct {
 		Token token.Token
 		Value bool
@@ -70,14 +143,84 @@ type (
 	BlockStatement struct {
 		Token      token.Token
 		Statements []Statement
+		Rbrace     token.Token
 	}
 
+	// IfExpression keeps `else if` branches flat in ElseIfs, tried in order
+	// after Condition and before falling back to Alternative.
 	IfExpression struct {
 		Token       token.Token
 		Condition   Expression
 		Consequence *BlockStatement
+		ElseIfs     []*ElseIf
 		Alternative *BlockStatement
 	}
+
+	ElseIf struct {
+		Token       token.Token
+		Condition   Expression
+		Consequence *BlockStatement
+	}
+
+	BadStatement struct {
+		From token.Token
+		To   token.Token
+	}
+
+	BadExpression struct {
+		From token.Token
+		To   token.Token
+	}
+
+	// FunctionLiteral.Name is the name it was bound to by a let statement,
+	// if any. It is only used to label stack traces.
+	FunctionLiteral struct {
+		Token      token.Token
+		Name       string
+		Parameters []*Identifier
+		Body       *BlockStatement
+	}
+
+	CallExpression struct {
+		Token     token.Token
+		Function  Expression
+		Arguments []Expression
+		Rparen    token.Token
+	}
+
+	ArrayLiteral struct {
+		Token    token.Token
+		Elements []Expression
+		Rbracket token.Token
+	}
+
+	IndexExpression struct {
+		Token    token.Token
+		Left     Expression
+		Index    Expression
+		Rbracket token.Token
+	}
+
+	// HashLiteral keeps its pairs in source order.
+	HashLiteral struct {
+		Token  token.Token
+		Pairs  []HashPair
+		Rbrace token.Token
+	}
+
+	HashPair struct {
+		Key   Expression
+		Value Expression
+	}
+
+	// SliceExpression is left[Low:High]; either bound may be nil.
+	SliceExpression struct {
+		Token    token.Token
+		Left     Expression
+		Low      Expression
+		High     Expression
+		Rbracket token.Token
+	}
 )
 
 func (p *Program) TokenLiteral() string {
@@ -96,6 +239,20 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+func (p *Program) End() token.Position {
+	if n := len(p.Statements); n > 0 {
+		return p.Statements[n-1].End()
+	}
+	return token.Position{}
+}
+
 func (ls *LetStatement) statementNode() {}
 
 func (ls *LetStatement) TokenLiteral() string {
@@ -113,6 +270,20 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
+func (ls *LetStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+	if ls.Name != nil {
+		return ls.Name.End()
+	}
+	return ls.Token.End
+}
+
 func (i *Identifier) expressionNode() {}
 
 func (i *Identifier) TokenLiteral() string {
@@ -122,6 +293,14 @@ func (i *Identifier) String() string {
 	return i.Value
 }
 
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
+
+func (i *Identifier) End() token.Position {
+	return i.Token.End
+}
+
 func (rs *ReturnStatement) statementNode() {}
 
 func (rs *ReturnStatement) TokenLiteral() string {
@@ -138,6 +317,176 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+
+func (rs *ReturnStatement) End() token.Position {
+	if rs.ReturnValue != nil {
+		return rs.ReturnValue.End()
+	}
+	return rs.Token.End
+}
+
+func (ts *ThrowStatement) statementNode() {}
+
+func (ts *ThrowStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ts.TokenLiteral() + " ")
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+func (ts *ThrowStatement) Pos() token.Position {
+	return ts.Token.Pos
+}
+
+func (ts *ThrowStatement) End() token.Position {
+	if ts.Value != nil {
+		return ts.Value.End()
+	}
+	return ts.Token.End
+}
+
+func (ws *WhileStatement) statementNode() {}
+
+func (ws *WhileStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("while (")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(") { ")
+	out.WriteString(ws.Body.String())
+	out.WriteString(" }")
+	return out.String()
+}
+
+func (ws *WhileStatement) Pos() token.Position {
+	return ws.Token.Pos
+}
+
+func (ws *WhileStatement) End() token.Position {
+	if ws.Body != nil {
+		return ws.Body.End()
+	}
+	return ws.Token.End
+}
+
+func (fs *ForStatement) statementNode() {}
+
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("for (")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") { ")
+	out.WriteString(fs.Body.String())
+	out.WriteString(" }")
+	return out.String()
+}
+
+func (fs *ForStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+
+func (fs *ForStatement) End() token.Position {
+	if fs.Body != nil {
+		return fs.Body.End()
+	}
+	return fs.Token.End
+}
+
+func (bs *BreakStatement) statementNode() {}
+
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BreakStatement) String() string {
+	return bs.Token.Literal + ";"
+}
+
+func (bs *BreakStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BreakStatement) End() token.Position {
+	return bs.Token.End
+}
+
+func (cs *ContinueStatement) statementNode() {}
+
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ContinueStatement) String() string {
+	return cs.Token.Literal + ";"
+}
+
+func (cs *ContinueStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+
+func (cs *ContinueStatement) End() token.Position {
+	return cs.Token.End
+}
+
+func (ts *TryStatement) statementNode() {}
+
+func (ts *TryStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("try { ")
+	out.WriteString(ts.Body.String())
+	out.WriteString(" }")
+	if ts.Catch != nil {
+		out.WriteString(" catch (" + ts.CatchParam.String() + ") { ")
+		out.WriteString(ts.Catch.String())
+		out.WriteString(" }")
+	}
+	if ts.Finally != nil {
+		out.WriteString(" finally { ")
+		out.WriteString(ts.Finally.String())
+		out.WriteString(" }")
+	}
+	return out.String()
+}
+
+func (ts *TryStatement) Pos() token.Position {
+	return ts.Token.Pos
+}
+
+func (ts *TryStatement) End() token.Position {
+	switch {
+	case ts.Finally != nil:
+		return ts.Finally.End()
+	case ts.Catch != nil:
+		return ts.Catch.End()
+	case ts.Body != nil:
+		return ts.Body.End()
+	}
+	return ts.Token.End
+}
+
 func (es *ExpressionStatement) statementNode() {}
 
 func (es *ExpressionStatement) TokenLiteral() string {
@@ -151,6 +500,20 @@ func (es *ExpressionStatement) String() string {
 	return ""
 }
 
+func (es *ExpressionStatement) Pos() token.Position {
+	if es.Expression != nil {
+		return es.Expression.Pos()
+	}
+	return es.Token.Pos
+}
+
+func (es *ExpressionStatement) End() token.Position {
+	if es.Expression != nil {
+		return es.Expression.End()
+	}
+	return es.Token.End
+}
+
 func (lt *IntegerLiteral) expressionNode() {}
 
 func (lt *IntegerLiteral) TokenLiteral() string {
@@ -161,6 +524,50 @@ func (lt *IntegerLiteral) String() string {
 	return lt.Token.Literal
 }
 
+func (lt *IntegerLiteral) Pos() token.Position {
+	return lt.Token.Pos
+}
+
+func (lt *IntegerLiteral) End() token.Position {
+	return lt.Token.End
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
+func (fl *FloatLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
+func (fl *FloatLiteral) End() token.Position {
+	return fl.Token.End
+}
+
+func (sl *StringLiteral) expressionNode() {}
+
+func (sl *StringLiteral) TokenLiteral() string {
+	return sl.Token.Literal
+}
+
+func (sl *StringLiteral) String() string {
+	return strconv.Quote(sl.Value)
+}
+
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Pos
+}
+
+func (sl *StringLiteral) End() token.Position {
+	return sl.Token.End
+}
+
 func (pe *PrefixExpression) expressionNode() {}
 
 func (pe *PrefixExpression) TokenLiteral() string {
@@ -176,6 +583,17 @@ func (pe *PrefixExpression) String() string {
 	return out.String()
 }
 
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+
+func (pe *PrefixExpression) End() token.Position {
+	if pe.Right != nil {
+		return pe.Right.End()
+	}
+	return pe.Token.End
+}
+
 func (ie *InfixExpression) expressionNode() {}
 
 func (ie *InfixExpression) String() string {
@@ -192,6 +610,80 @@ func (ie *InfixExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *InfixExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Pos
+}
+
+func (ie *InfixExpression) End() token.Position {
+	if ie.Right != nil {
+		return ie.Right.End()
+	}
+	return ie.Token.End
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+func (ae *AssignExpression) Pos() token.Position {
+	if ae.Target != nil {
+		return ae.Target.Pos()
+	}
+	return ae.Token.Pos
+}
+
+func (ae *AssignExpression) End() token.Position {
+	if ae.Value != nil {
+		return ae.Value.End()
+	}
+	return ae.Token.End
+}
+
+func (le *LogicalExpression) expressionNode() {}
+
+func (le *LogicalExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(le.Left.String())
+	out.WriteString(" " + le.Operator + " ")
+	out.WriteString(le.Right.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+func (le *LogicalExpression) TokenLiteral() string {
+	return le.Token.Literal
+}
+
+func (le *LogicalExpression) Pos() token.Position {
+	if le.Left != nil {
+		return le.Left.Pos()
+	}
+	return le.Token.Pos
+}
+
+func (le *LogicalExpression) End() token.Position {
+	if le.Right != nil {
+		return le.Right.End()
+	}
+	return le.Token.End
+}
+
 func (b *Boolean) expressionNode() {}
 
 func (b *Boolean) TokenLiteral() string {
@@ -202,6 +694,14 @@ func (b *Boolean) String() string {
 	return b.Token.Literal
 }
 
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Pos
+}
+
+func (b *Boolean) End() token.Position {
+	return b.Token.End
+}
+
 func (bs *BlockStatement) expressionNode() {}
 
 func (bs *BlockStatement) TokenLiteral() string {
@@ -216,6 +716,20 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BlockStatement) End() token.Position {
+	if bs.Rbrace.End.IsValid() {
+		return bs.Rbrace.End
+	}
+	if n := len(bs.Statements); n > 0 {
+		return bs.Statements[n-1].End()
+	}
+	return bs.Token.End
+}
+
 func (ie *IfExpression) expressionNode() {}
 
 func (ie *IfExpression) TokenLiteral() string {
@@ -229,6 +743,13 @@ func (ie *IfExpression) String() string {
 	out.WriteString(" ")
 	out.WriteString(ie.Consequence.String())
 
+	for _, elseIf := range ie.ElseIfs {
+		out.WriteString("else if")
+		out.WriteString(elseIf.Condition.String())
+		out.WriteString(" ")
+		out.WriteString(elseIf.Consequence.String())
+	}
+
 	if ie.Alternative != nil {
 		out.WriteString("else")
 		out.WriteString(ie.Alternative.String())
@@ -236,3 +757,244 @@ func (ie *IfExpression) String() string {
 
 	return out.String()
 }
+
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if n := len(ie.ElseIfs); n > 0 && ie.ElseIfs[n-1].Consequence != nil {
+		return ie.ElseIfs[n-1].Consequence.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return ie.Token.End
+}
+
+func (fl *FunctionLiteral) expressionNode() {}
+
+func (fl *FunctionLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+func (fl *FunctionLiteral) String() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range fl.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString(fl.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(fl.Body.String())
+	return out.String()
+}
+
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
+func (fl *FunctionLiteral) End() token.Position {
+	if fl.Body != nil {
+		return fl.Body.End()
+	}
+	return fl.Token.End
+}
+
+func (ce *CallExpression) expressionNode() {}
+
+func (ce *CallExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+	args := []string{}
+	for _, a := range ce.Arguments {
+		args = append(args, a.String())
+	}
+	out.WriteString(ce.Function.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
+func (ce *CallExpression) Pos() token.Position {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+	return ce.Token.Pos
+}
+
+func (ce *CallExpression) End() token.Position {
+	if ce.Rparen.End.IsValid() {
+		return ce.Rparen.End
+	}
+	return ce.Token.End
+}
+
+func (al *ArrayLiteral) expressionNode() {}
+
+func (al *ArrayLiteral) TokenLiteral() string {
+	return al.Token.Literal
+}
+
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Pos
+}
+
+func (al *ArrayLiteral) End() token.Position {
+	if al.Rbracket.End.IsValid() {
+		return al.Rbracket.End
+	}
+	return al.Token.End
+}
+
+func (hl *HashLiteral) expressionNode() {}
+
+func (hl *HashLiteral) TokenLiteral() string {
+	return hl.Token.Literal
+}
+
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+	pairs := []string{}
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+func (hl *HashLiteral) Pos() token.Position {
+	return hl.Token.Pos
+}
+
+func (hl *HashLiteral) End() token.Position {
+	if hl.Rbrace.End.IsValid() {
+		return hl.Rbrace.End
+	}
+	return hl.Token.End
+}
+
+func (ie *IndexExpression) expressionNode() {}
+
+func (ie *IndexExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+	return out.String()
+}
+
+func (ie *IndexExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Pos
+}
+
+func (ie *IndexExpression) End() token.Position {
+	if ie.Rbracket.End.IsValid() {
+		return ie.Rbracket.End
+	}
+	return ie.Token.End
+}
+
+func (se *SliceExpression) expressionNode() {}
+
+func (se *SliceExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+	out.WriteString("])")
+	return out.String()
+}
+
+func (se *SliceExpression) Pos() token.Position {
+	if se.Left != nil {
+		return se.Left.Pos()
+	}
+	return se.Token.Pos
+}
+
+func (se *SliceExpression) End() token.Position {
+	if se.Rbracket.End.IsValid() {
+		return se.Rbracket.End
+	}
+	return se.Token.End
+}
+
+func (bs *BadStatement) statementNode() {}
+
+func (bs *BadStatement) TokenLiteral() string {
+	return bs.From.Literal
+}
+
+func (bs *BadStatement) String() string {
+	return "<bad statement>"
+}
+
+func (bs *BadStatement) Pos() token.Position {
+	return bs.From.Pos
+}
+
+func (bs *BadStatement) End() token.Position {
+	return bs.To.End
+}
+
+func (be *BadExpression) expressionNode() {}
+
+func (be *BadExpression) TokenLiteral() string {
+	return be.From.Literal
+}
+
+func (be *BadExpression) String() string {
+	return "<bad expression>"
+}
+
+func (be *BadExpression) Pos() token.Position {
+	return be.From.Pos
+}
+
+func (be *BadExpression) End() token.Position {
+	return be.To.End
+}
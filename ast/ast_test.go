@@ -25,3 +25,45 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+func TestNodePositions(t *testing.T) {
+	pos := func(offset, column int) token.Position {
+		return token.Position{Offset: offset, Line: 1, Column: column}
+	}
+	// a + b(c)
+	left := &Identifier{Token: token.Token{Type: token.IDENT, Literal: "a", Pos: pos(0, 1), End: pos(1, 2)}, Value: "a"}
+	fn := &Identifier{Token: token.Token{Type: token.IDENT, Literal: "b", Pos: pos(4, 5), End: pos(5, 6)}, Value: "b"}
+	arg := &Identifier{Token: token.Token{Type: token.IDENT, Literal: "c", Pos: pos(6, 7), End: pos(7, 8)}, Value: "c"}
+	call := &CallExpression{
+		Token:     token.Token{Type: token.LPAREN, Literal: "(", Pos: pos(5, 6), End: pos(6, 7)},
+		Function:  fn,
+		Arguments: []Expression{arg},
+		Rparen:    token.Token{Type: token.RPAREN, Literal: ")", Pos: pos(7, 8), End: pos(8, 9)},
+	}
+	infix := &InfixExpression{
+		Token:    token.Token{Type: token.PLUS, Literal: "+", Pos: pos(2, 3), End: pos(3, 4)},
+		Left:     left,
+		Operator: "+",
+		Right:    call,
+	}
+	program := &Program{Statements: []Statement{&ExpressionStatement{Token: left.Token, Expression: infix}}}
+
+	tests := []struct {
+		node        Node
+		expectedPos token.Position
+		expectedEnd token.Position
+	}{
+		{left, pos(0, 1), pos(1, 2)},
+		{call, pos(4, 5), pos(8, 9)},
+		{infix, pos(0, 1), pos(8, 9)},
+		{program, pos(0, 1), pos(8, 9)},
+	}
+	for i, tt := range tests {
+		if tt.node.Pos() != tt.expectedPos {
+			t.Errorf("tests[%d] - Pos() wrong. expected=%+v, got=%+v", i, tt.expectedPos, tt.node.Pos())
+		}
+		if tt.node.End() != tt.expectedEnd {
+			t.Errorf("tests[%d] - End() wrong. expected=%+v, got=%+v", i, tt.expectedEnd, tt.node.End())
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package diag
+
+import (
+	"fmt"
+	"io"
+	"monkey-lang/token"
+	"strings"
+	"unicode/utf8"
+)
+
+type (
+	Severity int
+
+	Code string
+
+	Diagnostic struct {
+		Severity Severity
+		Code     Code
+		Span     token.Span
+		Message  string
+		Expected []token.TokenType
+		Found    token.TokenType
+		Hint     string
+	}
+)
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+// Codes are stable: tests and tools match on them, so never renumber an
+// existing entry, only append new ones.
+const (
+	UnexpectedToken     Code = "E0001"
+	MissingPrefix       Code = "E0002"
+	InvalidInteger      Code = "E0003"
+	IllegalCharacter    Code = "E0004"
+	UnterminatedString  Code = "E0005"
+	InvalidEscape       Code = "E0006"
+	UnterminatedComment Code = "E0007"
+	ReadError           Code = "E0008"
+	MalformedNumber     Code = "E0009"
+	InvalidFloat        Code = "E0010"
+	InvalidAssignment   Code = "E0011"
+)
+
+var catalog = map[Code]string{
+	UnexpectedToken:     "unexpected token",
+	MissingPrefix:       "token cannot start an expression",
+	InvalidInteger:      "invalid integer literal",
+	IllegalCharacter:    "illegal character",
+	UnterminatedString:  "unterminated string literal",
+	InvalidEscape:       "invalid escape sequence",
+	UnterminatedComment: "unterminated block comment",
+	ReadError:           "input could not be read",
+	MalformedNumber:     "malformed number literal",
+	InvalidFloat:        "invalid float literal",
+	InvalidAssignment:   "invalid assignment target",
+}
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+func (c Code) Title() string {
+	if title, ok := catalog[c]; ok {
+		return title
+	}
+	return "unknown diagnostic"
+}
+
+func (d *Diagnostic) Error() string {
+	return d.Span.Start.String() + ": " + d.Message
+}
+
+// Render writes d in long form: the header, the offending source line taken
+// from src, a caret underline below the span and the fix hint if any.
+func Render(w io.Writer, src string, d *Diagnostic) {
+	fmt.Fprintf(w, "%s: %s[%s]: %s\n", d.Span.Start, d.Severity, d.Code, d.Message)
+	start := d.Span.Start
+	if !start.IsValid() || start.Offset > len(src) {
+		renderHint(w, "", d)
+		return
+	}
+	lineStart := strings.LastIndexByte(src[:start.Offset], '\n') + 1
+	lineEnd := strings.IndexByte(src[start.Offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(src)
+	} else {
+		lineEnd += start.Offset
+	}
+	line := strings.TrimRight(src[lineStart:lineEnd], "\r")
+
+	gutter := fmt.Sprintf("%d", start.Line)
+	pad := strings.Repeat(" ", len(gutter))
+	fmt.Fprintf(w, "%s | %s\n", gutter, line)
+
+	var indent strings.Builder
+	for _, r := range src[lineStart:start.Offset] {
+		if r == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+	}
+	width := 1
+	if end := d.Span.End; end.Line == start.Line && end.Column > start.Column {
+		width = end.Column - start.Column
+	} else if end.Line > start.Line {
+		width = utf8.RuneCountInString(src[start.Offset:lineEnd])
+	}
+	if width < 1 {
+		width = 1
+	}
+	fmt.Fprintf(w, "%s | %s%s\n", pad, indent.String(), strings.Repeat("^", width))
+	renderHint(w, pad, d)
+}
+
+func renderHint(w io.Writer, pad string, d *Diagnostic) {
+	if d.Hint != "" {
+		fmt.Fprintf(w, "%s = hint: %s\n", pad, d.Hint)
+	}
+}
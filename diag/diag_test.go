@@ -0,0 +1,79 @@
+package diag
+
+import (
+	"bytes"
+	"monkey-lang/token"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		src      string
+		d        *Diagnostic
+		expected string
+	}{
+		{
+			"let = 5;",
+			&Diagnostic{
+				Code:    UnexpectedToken,
+				Span:    token.Span{Start: token.Position{Filename: "a.mk", Offset: 4, Line: 1, Column: 5}, End: token.Position{Filename: "a.mk", Offset: 5, Line: 1, Column: 6}},
+				Message: "expect next token to be INDENT, got =",
+				Hint:    "name it",
+			},
+			"a.mk:1:5: error[E0001]: expect next token to be INDENT, got =\n" +
+				"1 | let = 5;\n" +
+				"  |     ^\n" +
+				"  = hint: name it\n",
+		},
+		{
+			"x;\n\tlet y = 99999999999999999999;\n",
+			&Diagnostic{
+				Code:    InvalidInteger,
+				Span:    token.Span{Start: token.Position{Offset: 12, Line: 2, Column: 10}, End: token.Position{Offset: 32, Line: 2, Column: 30}},
+				Message: "could not convert",
+			},
+			"2:10: error[E0003]: could not convert\n" +
+				"2 | \tlet y = 99999999999999999999;\n" +
+				"  | \t        ^^^^^^^^^^^^^^^^^^^^\n",
+		},
+		{
+			"é + 1",
+			&Diagnostic{
+				Severity: Warning,
+				Code:     MissingPrefix,
+				Span:     token.Span{Start: token.Position{Offset: 3, Line: 1, Column: 3}, End: token.Position{Offset: 4, Line: 1, Column: 4}},
+				Message:  "plus",
+			},
+			"1:3: warning[E0002]: plus\n" +
+				"1 | é + 1\n" +
+				"  |   ^\n",
+		},
+	}
+	for i, tt := range tests {
+		var out bytes.Buffer
+		Render(&out, tt.src, tt.d)
+		if out.String() != tt.expected {
+			t.Errorf("tests[%d] - render wrong.\nexpected=\n%s\ngot=\n%s", i, tt.expected, out.String())
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	tests := []struct {
+		code     Code
+		expected string
+	}{
+		{UnexpectedToken, "E0001"},
+		{MissingPrefix, "E0002"},
+		{InvalidInteger, "E0003"},
+		{InvalidAssignment, "E0011"},
+	}
+	for _, tt := range tests {
+		if string(tt.code) != tt.expected {
+			t.Errorf("code changed. expected=%s, got=%s", tt.expected, tt.code)
+		}
+		if tt.code.Title() == "unknown diagnostic" {
+			t.Errorf("code %s has no catalog entry", tt.code)
+		}
+	}
+}
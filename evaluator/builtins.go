@@ -0,0 +1,246 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"monkey-lang/object"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Register makes b callable by name from scripts run by this interpreter,
+// replacing any builtin with the same name. Names bound in the environment
+// still take precedence.
+func (in *Interpreter) Register(b *object.Builtin) {
+	in.builtins[b.Name] = b
+}
+
+// Unregister removes the builtin called name, if any.
+func (in *Interpreter) Unregister(name string) {
+	delete(in.builtins, name)
+}
+
+// Builtin looks up a registered builtin by name.
+func (in *Interpreter) Builtin(name string) (*object.Builtin, bool) {
+	b, ok := in.builtins[name]
+	return b, ok
+}
+
+// WithBuiltin registers an additional builtin, or replaces a standard one.
+func WithBuiltin(b *object.Builtin) Option {
+	return func(in *Interpreter) {
+		in.Register(b)
+	}
+}
+
+// WithoutBuiltins removes the named builtins from the interpreter.
+func WithoutBuiltins(names ...string) Option {
+	return func(in *Interpreter) {
+		for _, name := range names {
+			in.Unregister(name)
+		}
+	}
+}
+
+func (in *Interpreter) registerStandardBuiltins() {
+	for _, b := range []*object.Builtin{
+		{Name: "len", MinArgs: 1, MaxArgs: 1, Fn: builtinLen},
+		{Name: "puts", MinArgs: 0, MaxArgs: -1, Fn: in.builtinPuts},
+		{Name: "first", MinArgs: 1, MaxArgs: 1, Fn: builtinFirst},
+		{Name: "last", MinArgs: 1, MaxArgs: 1, Fn: builtinLast},
+		{Name: "rest", MinArgs: 1, MaxArgs: 1, Fn: builtinRest},
+		{Name: "push", MinArgs: 2, MaxArgs: 2, Fn: builtinPush},
+		{Name: "type", MinArgs: 1, MaxArgs: 1, Fn: builtinType},
+		{Name: "str", MinArgs: 1, MaxArgs: 1, Fn: builtinStr},
+		{Name: "int", MinArgs: 1, MaxArgs: 1, Fn: in.builtinInt},
+		{Name: "range", MinArgs: 1, MaxArgs: 3, Fn: builtinRange},
+	} {
+		in.Register(b)
+	}
+}
+
+func applyBuiltin(b *object.Builtin, args []object.Object) object.Object {
+	if len(args) < b.MinArgs || b.MaxArgs >= 0 && len(args) > b.MaxArgs {
+		return newError("wrong number of arguments to `%s`: want=%s, got=%d", b.Name, arity(b), len(args))
+	}
+	return b.Fn(args...)
+}
+
+func arity(b *object.Builtin) string {
+	switch {
+	case b.MaxArgs < 0:
+		return fmt.Sprintf("%d or more", b.MinArgs)
+	case b.MinArgs == b.MaxArgs:
+		return strconv.Itoa(b.MinArgs)
+	default:
+		return fmt.Sprintf("%d to %d", b.MinArgs, b.MaxArgs)
+	}
+}
+
+func unsupportedArgument(name string, arg object.Object) *object.Error {
+	return newError("argument to `%s` not supported, got %s", name, arg.Type())
+}
+
+func builtinLen(args ...object.Object) object.Object {
+	switch arg := args[0].(type) {
+	case *object.String:
+		return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+	case *object.Array:
+		return &object.Integer{Value: int64(len(arg.Elements))}
+	case *object.Hash:
+		return &object.Integer{Value: int64(len(arg.Keys))}
+	case *object.Range:
+		return &object.Integer{Value: arg.Len()}
+	default:
+		return unsupportedArgument("len", arg)
+	}
+}
+
+func (in *Interpreter) builtinPuts(args ...object.Object) object.Object {
+	for _, arg := range args {
+		fmt.Fprintln(in.out, arg.Inspect())
+	}
+	return NULL
+}
+
+func builtinFirst(args ...object.Object) object.Object {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return unsupportedArgument("first", args[0])
+	}
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+	return array.Elements[0]
+}
+
+func builtinLast(args ...object.Object) object.Object {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return unsupportedArgument("last", args[0])
+	}
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+	return array.Elements[len(array.Elements)-1]
+}
+
+// builtinRest returns a new array without the first element, or null for an
+// empty array.
+func builtinRest(args ...object.Object) object.Object {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return unsupportedArgument("rest", args[0])
+	}
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+	elements := make([]object.Object, len(array.Elements)-1)
+	copy(elements, array.Elements[1:])
+	return &object.Array{Elements: elements}
+}
+
+// builtinPush returns a new array; the argument is left unchanged.
+func builtinPush(args ...object.Object) object.Object {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return unsupportedArgument("push", args[0])
+	}
+	elements := make([]object.Object, len(array.Elements), len(array.Elements)+1)
+	copy(elements, array.Elements)
+	return &object.Array{Elements: append(elements, args[1])}
+}
+
+func builtinType(args ...object.Object) object.Object {
+	return &object.String{Value: string(args[0].Type())}
+}
+
+func builtinStr(args ...object.Object) object.Object {
+	if str, ok := args[0].(*object.String); ok {
+		return str
+	}
+	return &object.String{Value: args[0].Inspect()}
+}
+
+// builtinInt converts strings, floats and booleans to integers. Strings may
+// use any literal form the lexer accepts; floats are truncated toward zero.
+func (in *Interpreter) builtinInt(args ...object.Object) object.Object {
+	switch arg := args[0].(type) {
+	case *object.Integer, *object.BigInteger:
+		return arg
+	case *object.Boolean:
+		if arg.Value {
+			return &object.Integer{Value: 1}
+		}
+		return &object.Integer{Value: 0}
+	case *object.Float:
+		if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+			return newError("cannot convert %s to integer", arg.Inspect())
+		}
+		truncated := math.Trunc(arg.Value)
+		if truncated >= -(1<<63) && truncated < 1<<63 {
+			return &object.Integer{Value: int64(truncated)}
+		}
+		if !in.bigInts {
+			return newError("cannot convert %s to integer: overflows int64", arg.Inspect())
+		}
+		value, _ := big.NewFloat(truncated).Int(nil)
+		return normalizeBig(value)
+	case *object.String:
+		text := strings.TrimSpace(arg.Value)
+		if hasLeadingZero(text) {
+			return newError("cannot convert %q to integer: leading zero", arg.Value)
+		}
+		value, err := strconv.ParseInt(text, 0, 64)
+		if err == nil {
+			return &object.Integer{Value: value}
+		}
+		if in.bigInts {
+			if value, ok := new(big.Int).SetString(text, 0); ok {
+				return normalizeBig(value)
+			}
+		}
+		return newError("cannot convert %q to integer", arg.Value)
+	default:
+		return unsupportedArgument("int", arg)
+	}
+}
+
+// hasLeadingZero reports whether text is a decimal with a leading zero, such
+// as 010. ParseInt would read it as octal; the lexer rejects it, so int does
+// too.
+func hasLeadingZero(text string) bool {
+	digits := strings.TrimLeft(text, "+-")
+	return len(digits) > 1 && digits[0] == '0' && strings.ContainsRune("0123456789_", rune(digits[1]))
+}
+
+// builtinRange follows Python: range(stop), range(start, stop) and
+// range(start, stop, step).
+func builtinRange(args ...object.Object) object.Object {
+	bounds := make([]int64, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case *object.Integer:
+			bounds[i] = arg.Value
+		case *object.BigInteger:
+			return newError("argument to `range` overflows int64: %s", arg.Inspect())
+		default:
+			return unsupportedArgument("range", arg)
+		}
+	}
+	r := &object.Range{Step: 1}
+	switch len(bounds) {
+	case 1:
+		r.Stop = bounds[0]
+	case 2:
+		r.Start, r.Stop = bounds[0], bounds[1]
+	case 3:
+		r.Start, r.Stop, r.Step = bounds[0], bounds[1], bounds[2]
+	}
+	if r.Step == 0 {
+		return newError("range step must not be zero")
+	}
+	return r
+}
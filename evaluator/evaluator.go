@@ -0,0 +1,824 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"monkey-lang/ast"
+	"monkey-lang/object"
+	"monkey-lang/token"
+	"os"
+	"strings"
+)
+
+var (
+	NULL     = &object.Null{}
+	TRUE     = &object.Boolean{Value: true}
+	FALSE    = &object.Boolean{Value: false}
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
+)
+
+type (
+	Interpreter struct {
+		bigInts   bool
+		out       io.Writer
+		builtins  map[string]*object.Builtin
+		callDepth int
+	}
+
+	Option func(*Interpreter)
+)
+
+func New(opts ...Option) *Interpreter {
+	in := &Interpreter{
+		out:      os.Stdout,
+		builtins: make(map[string]*object.Builtin),
+	}
+	in.registerStandardBuiltins()
+	for _, opt := range opts {
+		opt(in)
+	}
+	return in
+}
+
+// WithBigInts backs integers with math/big when they leave the int64 range:
+// results that overflow are promoted instead of wrapping and literals of any
+// size are accepted. Values that fit stay plain int64 Integers.
+func WithBigInts() Option {
+	return func(in *Interpreter) {
+		in.bigInts = true
+	}
+}
+
+// WithOutput sends the output of puts to w instead of standard output.
+func WithOutput(w io.Writer) Option {
+	return func(in *Interpreter) {
+		in.out = w
+	}
+}
+
+var defaultInterpreter = New()
+
+// Eval evaluates node with the default interpreter settings.
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	return defaultInterpreter.Eval(node, env)
+}
+
+// Eval evaluates node in env. An error produced while evaluating node is
+// tagged with the span of the innermost node that failed.
+func (in *Interpreter) Eval(node ast.Node, env *object.Environment) object.Object {
+	result := in.eval(node, env)
+	if err, ok := result.(*object.Error); ok && !err.Span.Start.IsValid() && node != nil {
+		err.Span = token.Span{Start: node.Pos(), End: node.End()}
+	}
+	return result
+}
+
+func (in *Interpreter) eval(node ast.Node, env *object.Environment) object.Object {
+	switch node := node.(type) {
+	case *ast.Program:
+		return in.evalProgram(node, env)
+	case *ast.ExpressionStatement:
+		return in.Eval(node.Expression, env)
+	case *ast.BlockStatement:
+		return in.evalBlockStatement(node, object.NewEnclosedEnvironment(env))
+	case *ast.LetStatement:
+		if node.Value == nil {
+			return newError("let statement without a value")
+		}
+		val := in.Eval(node.Value, env)
+		if isInterrupt(val) {
+			return val
+		}
+		env.Set(node.Name.Value, val)
+	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			return newError("return statement without a value")
+		}
+		val := in.Eval(node.ReturnValue, env)
+		if isInterrupt(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *ast.ThrowStatement:
+		val := in.Eval(node.Value, env)
+		if isInterrupt(val) {
+			return val
+		}
+		return &object.Error{Message: thrownMessage(val), Value: val}
+	case *ast.TryStatement:
+		return in.evalTryStatement(node, env)
+	case *ast.WhileStatement:
+		return in.evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return in.evalForStatement(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
+	case *ast.IntegerLiteral:
+		return in.evalIntegerLiteral(node)
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+	case *ast.Identifier:
+		return in.evalIdentifier(node, env)
+	case *ast.PrefixExpression:
+		right := in.Eval(node.Right, env)
+		if isInterrupt(right) {
+			return right
+		}
+		return in.evalPrefixExpression(node.Operator, right)
+	case *ast.InfixExpression:
+		left := in.Eval(node.Left, env)
+		if isInterrupt(left) {
+			return left
+		}
+		right := in.Eval(node.Right, env)
+		if isInterrupt(right) {
+			return right
+		}
+		return in.evalInfixExpression(node.Operator, left, right)
+	case *ast.FunctionLiteral:
+		return &object.Function{Name: node.Name, Parameters: node.Parameters, Body: node.Body, Env: env}
+	case *ast.CallExpression:
+		function := in.Eval(node.Function, env)
+		if isInterrupt(function) {
+			return function
+		}
+		args := in.evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isInterrupt(args[0]) {
+			return args[0]
+		}
+		return in.applyFunction(node, function, args)
+	case *ast.ArrayLiteral:
+		elements := in.evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isInterrupt(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.IndexExpression:
+		left := in.Eval(node.Left, env)
+		if isInterrupt(left) {
+			return left
+		}
+		index := in.Eval(node.Index, env)
+		if isInterrupt(index) {
+			return index
+		}
+		return evalIndexExpression(left, index)
+	case *ast.SliceExpression:
+		return in.evalSliceExpression(node, env)
+	case *ast.HashLiteral:
+		return in.evalHashLiteral(node, env)
+	case *ast.AssignExpression:
+		return in.evalAssignExpression(node, env)
+	case *ast.LogicalExpression:
+		return in.evalLogicalExpression(node, env)
+	case *ast.IfExpression:
+		return in.evalIfExpression(node, env)
+	case *ast.BadStatement, *ast.BadExpression:
+		return newError("invalid syntax at %s", node.Pos())
+	default:
+		return newError("unsupported node: %T", node)
+	}
+	return nil
+}
+
+func (in *Interpreter) evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+	for _, stm := range program.Statements {
+		result = in.Eval(stm, env)
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+	return result
+}
+
+// evalBlockStatement runs block in env. Callers pass a fresh enclosed
+// environment so names declared in the block do not leak out of it.
+func (in *Interpreter) evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+	for _, stm := range block.Statements {
+		result = in.Eval(stm, env)
+		if isInterrupt(result) {
+			return result
+		}
+	}
+	if result == nil {
+		// An empty block, or one ending in a let, still has to produce a
+		// value when used as an expression.
+		return NULL
+	}
+	return result
+}
+
+// isInterrupt reports whether obj stops the enclosing block early: a return,
+// an error, or a break or continue. Sub-expressions pass these up unchanged,
+// since an if used as a value can return, break or continue.
+func isInterrupt(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+	switch obj.Type() {
+	case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+		return true
+	}
+	return false
+}
+
+// maxCallDepth bounds nested function calls, so runaway recursion ends in a
+// Monkey error instead of overflowing the Go stack.
+const maxCallDepth = 10000
+
+func (in *Interpreter) applyFunction(call *ast.CallExpression, fn object.Object, args []object.Object) object.Object {
+	if builtin, ok := fn.(*object.Builtin); ok {
+		return applyBuiltin(builtin, args)
+	}
+	function, ok := fn.(*object.Function)
+	if !ok {
+		return newError("not a function: %s", fn.Type())
+	}
+	if len(args) != len(function.Parameters) {
+		return newError("wrong number of arguments: want=%d, got=%d", len(function.Parameters), len(args))
+	}
+	if in.callDepth >= maxCallDepth {
+		return newError("stack overflow: more than %d nested calls", maxCallDepth)
+	}
+	env := object.NewEnclosedEnvironment(function.Env)
+	for i, param := range function.Parameters {
+		env.Set(param.Value, args[i])
+	}
+	in.callDepth++
+	result := unwrapReturnValue(in.evalBlockStatement(function.Body, env))
+	in.callDepth--
+	if err, ok := result.(*object.Error); ok {
+		err.Stack = append(err.Stack, object.Frame{Function: functionName(function), Call: call.Pos()})
+	}
+	return result
+}
+
+func functionName(fn *object.Function) string {
+	if fn.Name == "" {
+		return "<anonymous>"
+	}
+	return fn.Name
+}
+
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	return obj
+}
+
+func (in *Interpreter) evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	if builtin, ok := in.builtins[node.Value]; ok {
+		return builtin
+	}
+	return newError("identifier not found: %s", node.Value)
+}
+
+func (in *Interpreter) evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return evalBangOperatorExpression(right)
+	case "-":
+		return in.evalMinusPrefixOperatorExpression(right)
+	case "~":
+		return evalTildePrefixOperatorExpression(right)
+	default:
+		return newError("unknown operator: %s%s", operator, right.Type())
+	}
+}
+
+func evalBangOperatorExpression(right object.Object) object.Object {
+	if isTruthy(right) {
+		return FALSE
+	}
+	return TRUE
+}
+
+func (in *Interpreter) evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 && in.bigInts {
+			return normalizeBig(new(big.Int).Neg(toBig(right)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInteger:
+		return normalizeBig(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return newError("unknown operator: -%s", right.Type())
+	}
+}
+
+func evalTildePrefixOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: ^right.Value}
+	case *object.BigInteger:
+		return normalizeBig(new(big.Int).Not(right.Value))
+	default:
+		return newError("unknown operator: ~%s", right.Type())
+	}
+}
+
+func (in *Interpreter) evalInfixExpression(operator string, left, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return in.evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalFloatInfixExpression handles float operands, promoting an integer on
+// either side. Division follows IEEE 754, so x / 0.0 is Inf rather than an
+// error.
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInteger:
+		return bigToFloat(obj.Value)
+	case *object.Float:
+		return obj.Value
+	}
+	return 0
+}
+
+func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func (in *Interpreter) evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := in.Eval(ie.Condition, env)
+	if isInterrupt(condition) {
+		return condition
+	}
+	if isTruthy(condition) {
+		return in.Eval(ie.Consequence, env)
+	}
+	for _, elseIf := range ie.ElseIfs {
+		condition := in.Eval(elseIf.Condition, env)
+		if isInterrupt(condition) {
+			return condition
+		}
+		if isTruthy(condition) {
+			return in.Eval(elseIf.Consequence, env)
+		}
+	}
+	if ie.Alternative != nil {
+		return in.Eval(ie.Alternative, env)
+	}
+	return NULL
+}
+
+// evalExpressions evaluates exps left to right. On the first error, return,
+// break or continue it returns a slice holding only that object.
+func (in *Interpreter) evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+	for _, e := range exps {
+		evaluated := in.Eval(e, env)
+		if isInterrupt(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+	return result
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(left.(*object.Array), index)
+	case left.Type() == object.ARRAY_OBJ:
+		return newError("array index must be INTEGER, got %s", index.Type())
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left.(*object.Hash), index)
+	default:
+		return newError("index operator not supported: %s", left.Type())
+	}
+}
+
+// evalArrayIndexExpression counts negative indexes from the end, so arr[-1]
+// is the last element. Anything outside the array is an error.
+func evalArrayIndexExpression(array *object.Array, index object.Object) object.Object {
+	length := int64(len(array.Elements))
+	i, ok := index.(*object.Integer)
+	if !ok || i.Value < -length || i.Value >= length {
+		return newError("index out of range: %s with length %d", index.Inspect(), length)
+	}
+	idx := i.Value
+	if idx < 0 {
+		idx += length
+	}
+	return array.Elements[idx]
+}
+
+func evalHashIndexExpression(hash *object.Hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+	value, ok := hash.Get(key)
+	if !ok {
+		return NULL
+	}
+	return value
+}
+
+func (in *Interpreter) evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+	for _, pair := range node.Pairs {
+		key := in.Eval(pair.Key, env)
+		if isInterrupt(key) {
+			return key
+		}
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+		value := in.Eval(pair.Value, env)
+		if isInterrupt(value) {
+			return value
+		}
+		hash.Set(hashKey, value)
+	}
+	return hash
+}
+
+// evalSliceExpression returns a new array with the elements from Low up to
+// but not including High. Negative bounds count from the end and bounds past
+// either end are clamped, so slicing never fails on an integer bound.
+func (in *Interpreter) evalSliceExpression(se *ast.SliceExpression, env *object.Environment) object.Object {
+	left := in.Eval(se.Left, env)
+	if isInterrupt(left) {
+		return left
+	}
+	array, ok := left.(*object.Array)
+	if !ok {
+		return newError("slice operator not supported: %s", left.Type())
+	}
+	length := int64(len(array.Elements))
+	low, err := in.evalSliceBound(se.Low, 0, length, env)
+	if err != nil {
+		return err
+	}
+	high, err := in.evalSliceBound(se.High, length, length, env)
+	if err != nil {
+		return err
+	}
+	if high < low {
+		high = low
+	}
+	elements := make([]object.Object, high-low)
+	copy(elements, array.Elements[low:high])
+	return &object.Array{Elements: elements}
+}
+
+func (in *Interpreter) evalSliceBound(exp ast.Expression, def, length int64, env *object.Environment) (int64, object.Object) {
+	if exp == nil {
+		return def, nil
+	}
+	bound := in.Eval(exp, env)
+	if isInterrupt(bound) {
+		return 0, bound
+	}
+	var value int64
+	switch bound := bound.(type) {
+	case *object.Integer:
+		value = bound.Value
+	case *object.BigInteger:
+		value = int64(bound.Value.Sign()) * length
+	default:
+		return 0, newError("slice index must be INTEGER, got %s", bound.Type())
+	}
+	if value < 0 {
+		value += length
+	}
+	return max(0, min(value, length)), nil
+}
+
+// evalAssignExpression stores into an existing variable, array element or hash
+// entry and yields the stored value. Compound operators such as += combine the
+// current value with the right-hand side using the matching infix operator.
+func (in *Interpreter) evalAssignExpression(ae *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := ae.Target.(type) {
+	case *ast.Identifier:
+		var current object.Object
+		if ae.Operator != "=" {
+			current = in.evalIdentifier(target, env)
+			if isError(current) {
+				return current
+			}
+		}
+		val := in.evalAssignedValue(ae, current, env)
+		if isInterrupt(val) {
+			return val
+		}
+		if !env.Assign(target.Value, val) {
+			return newError("assignment to undeclared variable: %s", target.Value)
+		}
+		return val
+	case *ast.IndexExpression:
+		left := in.Eval(target.Left, env)
+		if isInterrupt(left) {
+			return left
+		}
+		index := in.Eval(target.Index, env)
+		if isInterrupt(index) {
+			return index
+		}
+		var current object.Object
+		if ae.Operator != "=" {
+			current = evalIndexExpression(left, index)
+			if isError(current) {
+				return current
+			}
+		}
+		val := in.evalAssignedValue(ae, current, env)
+		if isInterrupt(val) {
+			return val
+		}
+		return evalIndexAssignment(left, index, val)
+	default:
+		return newError("cannot assign to %s", ae.Target)
+	}
+}
+
+func (in *Interpreter) evalAssignedValue(ae *ast.AssignExpression, current object.Object, env *object.Environment) object.Object {
+	val := in.Eval(ae.Value, env)
+	if isInterrupt(val) || current == nil {
+		return val
+	}
+	return in.evalInfixExpression(strings.TrimSuffix(ae.Operator, "="), current, val)
+}
+
+func evalIndexAssignment(left, index, val object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		i, ok := index.(*object.Integer)
+		if !ok {
+			if index.Type() == object.INTEGER_OBJ {
+				return newError("index out of range: %s with length %d", index.Inspect(), len(left.Elements))
+			}
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		length := int64(len(left.Elements))
+		if i.Value < -length || i.Value >= length {
+			return newError("index out of range: %d with length %d", i.Value, length)
+		}
+		idx := i.Value
+		if idx < 0 {
+			idx += length
+		}
+		left.Elements[idx] = val
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		left.Set(key, val)
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+	return val
+}
+
+// evalLogicalExpression yields the operand that decided the result, like
+// Python's and/or, so `x || default` picks the first truthy value.
+func (in *Interpreter) evalLogicalExpression(le *ast.LogicalExpression, env *object.Environment) object.Object {
+	left := in.Eval(le.Left, env)
+	if isInterrupt(left) {
+		return left
+	}
+	switch le.Operator {
+	case "&&":
+		if !isTruthy(left) {
+			return left
+		}
+	case "||":
+		if isTruthy(left) {
+			return left
+		}
+	default:
+		return newError("unknown operator: %s %s", le.Operator, left.Type())
+	}
+	return in.Eval(le.Right, env)
+}
+
+// evalTryStatement runs the catch block if the body fails, binding the error
+// as a hash with "message", "stack" and "value" keys. The finally block always
+// runs afterwards; its own result is dropped unless it returns, fails, breaks
+// or continues, in which case that replaces the outcome of the body and catch
+// blocks.
+func (in *Interpreter) evalTryStatement(ts *ast.TryStatement, env *object.Environment) object.Object {
+	result := in.Eval(ts.Body, env)
+	if err, ok := result.(*object.Error); ok && ts.Catch != nil {
+		catchEnv := object.NewEnclosedEnvironment(env)
+		catchEnv.Set(ts.CatchParam.Value, caughtError(err))
+		result = in.evalBlockStatement(ts.Catch, catchEnv)
+	}
+	if ts.Finally != nil {
+		final := in.Eval(ts.Finally, env)
+		if isInterrupt(final) {
+			return final
+		}
+	}
+	return result
+}
+
+func caughtError(err *object.Error) *object.Hash {
+	stack := []object.Object{}
+	for _, line := range err.StackLines() {
+		stack = append(stack, &object.String{Value: line})
+	}
+	value := err.Value
+	if value == nil {
+		value = NULL
+	}
+	hash := object.NewHash()
+	hash.Set(&object.String{Value: "message"}, &object.String{Value: err.Message})
+	hash.Set(&object.String{Value: "stack"}, &object.Array{Elements: stack})
+	hash.Set(&object.String{Value: "value"}, value)
+	return hash
+}
+
+// thrownMessage is the message of an error raised by throw. Throwing a
+// caught error again keeps its message.
+func thrownMessage(val object.Object) string {
+	switch val := val.(type) {
+	case *object.String:
+		return val.Value
+	case *object.Hash:
+		if message, ok := val.Get(&object.String{Value: "message"}); ok {
+			if message, ok := message.(*object.String); ok {
+				return message.Value
+			}
+		}
+	}
+	return val.Inspect()
+}
+
+func (in *Interpreter) evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := in.Eval(ws.Condition, env)
+		if isInterrupt(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return NULL
+		}
+		result := in.Eval(ws.Body, env)
+		if result == BREAK {
+			return NULL
+		}
+		if result != CONTINUE && isInterrupt(result) {
+			return result
+		}
+	}
+}
+
+// evalForStatement iterates over the elements of an array, the keys of a hash
+// in insertion order, or the integers of a range. Each iteration gets a fresh
+// scope, so closures created in the body capture that iteration's variable.
+func (in *Interpreter) evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := in.Eval(fs.Iterable, env)
+	if isInterrupt(iterable) {
+		return iterable
+	}
+	var length int64
+	var item func(i int64) object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		elements := iterable.Elements
+		length = int64(len(elements))
+		item = func(i int64) object.Object { return elements[i] }
+	case *object.Hash:
+		keys := iterable.Keys
+		length = int64(len(keys))
+		item = func(i int64) object.Object { return iterable.Pairs[keys[i]].Key }
+	case *object.Range:
+		length = iterable.Len()
+		item = func(i int64) object.Object { return &object.Integer{Value: iterable.At(i)} }
+	default:
+		return newError("cannot iterate over %s", iterable.Type())
+	}
+	for i := int64(0); i < length; i++ {
+		loopEnv := object.NewEnclosedEnvironment(env)
+		loopEnv.Set(fs.Variable.Value, item(i))
+		result := in.evalBlockStatement(fs.Body, loopEnv)
+		if result == BREAK {
+			break
+		}
+		if result != CONTINUE && isInterrupt(result) {
+			return result
+		}
+	}
+	return NULL
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL:
+		return false
+	case TRUE:
+		return true
+	case FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+func isError(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.ERROR_OBJ
+	}
+	return false
+}
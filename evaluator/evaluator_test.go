@@ -0,0 +1,1710 @@
+package evaluator
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"monkey-lang/ast"
+	"monkey-lang/lexer"
+	"monkey-lang/object"
+	"monkey-lang/parser"
+	"monkey-lang/token"
+	"testing"
+)
+
+func TestEvalIntegerExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"10", 10},
+		{"-5", -5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"5 * 2 + 10", 20},
+		{"5 + 2 * 10", 25},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+		{"2 * (5 + 10)", 30},
+		{"3 * 3 * 3 + 10", 37},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 < 1", false},
+		{"1 > 1", false},
+		{"1 <= 1", true},
+		{"2 <= 1", false},
+		{"1 >= 1", true},
+		{"1 >= 2", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1 == 2", false},
+		{"1 != 2", true},
+		{"true == true", true},
+		{"false == false", true},
+		{"true == false", false},
+		{"true != false", true},
+		{"false != true", true},
+		{"(1 < 2) == true", true},
+		{"(1 < 2) == false", false},
+		{"(1 > 2) == true", false},
+		{"(1 > 2) == false", true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBangOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"!true", false},
+		{"!false", true},
+		{"!5", false},
+		{"!!true", true},
+		{"!!false", false},
+		{"!!5", true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIfElseExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1) { 10 }", 10},
+		{"if (1 < 2) { 10 }", 10},
+		{"if (1 > 2) { 10 }", nil},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"return 2 * 5; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"9; if (true) { return 10; }; 9;", 10},
+		{"if (10 > 1) { if (10 > 1) { return 10; }; return 1; }", 10},
+		{"let f = fn() { let y = if (true) { return 10; } else { 0 }; 2 }; f()", 10},
+		{"let f = fn() { return if (true) { return 10; } else { 0 }; }; f() + 0", 10},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+		{"let a = 5\nlet b = a + 1\nb", 6},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"5 + true;", "type mismatch: INTEGER + BOOLEAN"},
+		{"5 + true; 5;", "type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"5; true + false; 5", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"if (10 > 1) { true + false; }", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"if (10 > 1) { if (10 > 1) { true + false; }; 1; }", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"foobar", "identifier not found: foobar"},
+		{"10 / 0", "division by zero: 10 / 0"},
+		{`"Hello" - "World"`, "unknown operator: STRING - STRING"},
+		{`"a" + 1`, "type mismatch: STRING + INTEGER"},
+		{`1.5 + "a"`, "type mismatch: FLOAT + STRING"},
+		{"-true + 1.0", "unknown operator: -BOOLEAN"},
+		{"let f = fn(x) { let y = x; }; f(1) + 1", "type mismatch: NULL + INTEGER"},
+		{"fn() {}() + 1", "type mismatch: NULL + INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+// unsupportedNode is a node the evaluator has no case for.
+type unsupportedNode struct{ ast.Identifier }
+
+func TestMalformedTree(t *testing.T) {
+	tests := []struct {
+		node            ast.Node
+		expectedMessage string
+	}{
+		{&ast.LetStatement{Name: &ast.Identifier{Value: "x"}}, "let statement without a value"},
+		{&ast.ReturnStatement{}, "return statement without a value"},
+		{&unsupportedNode{}, "unsupported node: *evaluator.unsupportedNode"},
+		{&ast.ExpressionStatement{Expression: &unsupportedNode{}}, "unsupported node: *evaluator.unsupportedNode"},
+	}
+	for _, tt := range tests {
+		evaluated := New().Eval(tt.node, object.NewEnvironment())
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func testEval(input string, opts ...Option) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	return New(opts...).Eval(program, env)
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != NULL {
+		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+		return false
+	}
+	return true
+}
+
+func TestStringLiteral(t *testing.T) {
+	evaluated := testEval(`"Hello World!"`)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+		{`let a = "x"; let b = a + "\n"; b + a`, "x\nx"},
+		{`"" + ""`, ""},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+}
+
+func TestStringComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+		{`"a" + "b" == "ab"`, true},
+	}
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestSubtractionWithoutSpaces(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let x = 5; x-1", 4},
+		{"let a = 5; let b = 3; a-b", 2},
+		{"let a = 5; let b = 3; a-b-1", 1},
+		{"let a = 5; a--a", 10},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14", 3.14},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3},
+		{"1 + 0.5", 1.5},
+		{"0.5 + 1", 1.5},
+		{"10 - 2.5", 7.5},
+		{"2 * 1.25", 2.5},
+		{"1 / 4.0", 0.25},
+		{"1e3 * 2", 2000},
+		{"let r = 2.0; 3.0 * r * r", 12},
+	}
+	for _, tt := range tests {
+		testFloatObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFloatComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1.5 < 2", true},
+		{"2 > 1.5", true},
+		{"1.0 == 1", true},
+		{"1 != 1.0", false},
+		{"0.1 + 0.2 == 0.3", false},
+		{"2.5 >= 2.5", true},
+		{"2.5 <= 2", false},
+	}
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"3.0", "3.0"},
+		{"1 / 4.0", "0.25"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{"1e21", "1e+21"},
+		{"1.5e-9", "1.5e-09"},
+		{"123456789.0", "123456789.0"},
+		{"-0.5", "-0.5"},
+		{"1 / 0.0", "Inf"},
+		{"-1 / 0.0", "-Inf"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("Inspect() wrong for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func TestBigIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"99999999999999999999", "99999999999999999999"},
+		{"-99999999999999999999", "-99999999999999999999"},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"-9223372036854775807 - 1", "-9223372036854775808"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808"},
+		{"99999999999999999999 / 3", "33333333333333333333"},
+		{"99999999999999999999 * 99999999999999999999", "9999999999999999999800000000000000000001"},
+		{"0x1_0000_0000_0000_0000", "18446744073709551616"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input, WithBigInts())
+		if evaluated.Type() != object.INTEGER_OBJ {
+			t.Errorf("wrong type for %q. got=%s (%s)", tt.input, evaluated.Type(), evaluated.Inspect())
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestBigIntegerDemotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"99999999999999999999 - 99999999999999999998", 1},
+		{"(9223372036854775807 + 1) - 1", 9223372036854775807},
+		{"18446744073709551616 / 4294967296", 4294967296},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input, WithBigInts()), tt.expected)
+	}
+}
+
+func TestBigIntegerComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"99999999999999999999 > 1", true},
+		{"1 < 99999999999999999999", true},
+		{"99999999999999999999 == 99999999999999999999", true},
+		{"99999999999999999999 != 99999999999999999998", true},
+		{"-99999999999999999999 >= 0", false},
+		{"99999999999999999999 <= 99999999999999999999", true},
+		{"99999999999999999999 > 1.5", true},
+	}
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input, WithBigInts()), tt.expected)
+	}
+}
+
+func TestBigIntegerErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		opts            []Option
+		expectedMessage string
+	}{
+		{"99999999999999999999", nil, "integer literal 99999999999999999999 overflows int64"},
+		{"99999999999999999999 / 0", []Option{WithBigInts()}, "division by zero: 99999999999999999999 / 0"},
+		{"99999999999999999999 + true", []Option{WithBigInts()}, "type mismatch: INTEGER + BOOLEAN"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input, tt.opts...)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestIntegerOverflowWithoutBigInts(t *testing.T) {
+	testIntegerObject(t, testEval("9223372036854775807 + 1"), -9223372036854775808)
+}
+
+const benchmarkArithmetic = "(5 + 10 * 2 + 15 / 3) * 2 + -10 - 1000 * 1000 / 7 + 42 * 42 - 3"
+
+func benchmarkEval(b *testing.B, input string, opts ...Option) {
+	program := parser.New(lexer.New(input)).ParseProgram()
+	in := New(opts...)
+	env := object.NewEnvironment()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		in.Eval(program, env)
+	}
+}
+
+func BenchmarkSmallIntegers(b *testing.B) {
+	benchmarkEval(b, benchmarkArithmetic)
+}
+
+func BenchmarkSmallIntegersWithBigInts(b *testing.B) {
+	benchmarkEval(b, benchmarkArithmetic, WithBigInts())
+}
+
+func BenchmarkBigIntegers(b *testing.B) {
+	benchmarkEval(b, "99999999999999999999 * 99999999999999999999 + 12345678901234567890 - 1", WithBigInts())
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		{"false && foobar", false},
+		{"true || foobar", true},
+		{"1 && 2", 2},
+		{"0 || 5", 0},
+		{"if (false) { 1 } || 7", 7},
+		{"let x = 5; x > 1 && x < 10", true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestLogicalOperatorErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"true && foobar", "identifier not found: foobar"},
+		{"false || -true", "unknown operator: -BOOLEAN"},
+		{"foobar || true", "identifier not found: foobar"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestArithmeticAndBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"7 % 3", 7 % 3},
+		{"-7 % 3", -7 % 3},
+		{"7 % -3", 7 % -3},
+		{"2 + 7 % 3 * 2", 4},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 2", 4},
+		{"(-3) ** 3", -27},
+		{"5 ** 0", 1},
+		{"2 ** -1", 0.5},
+		{"2.0 ** 0.5", math.Sqrt2},
+		{"7.5 % 2", 1.5},
+		{"12 & 10", 8},
+		{"12 | 10", 14},
+		{"12 ^ 10", 6},
+		{"~0", -1},
+		{"~5", -6},
+		{"1 << 10", 1024},
+		{"-16 >> 2", -4},
+		{"1 << 64", 0},
+		{"6 & 3 == 2", true},
+		{"1 << 2 + 1", 8},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestBigIntegerOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 ** 64", "18446744073709551616"},
+		{"3 ** 40", "12157665459056928801"},
+		{"(-2) ** 63", "-9223372036854775808"},
+		{"1 << 64", "18446744073709551616"},
+		{"-1 << 63", "-9223372036854775808"},
+		{"(1 << 64) >> 63", "2"},
+		{"(2 ** 70 + 5) % 2 ** 64", "5"},
+		{"(2 ** 70) & (2 ** 70 + 1)", "1180591620717411303424"},
+		{"(2 ** 70) | 1", "1180591620717411303425"},
+		{"(2 ** 70) ^ (2 ** 70)", "0"},
+		{"~(2 ** 70)", "-1180591620717411303425"},
+		{"1 ** (2 ** 70)", "1"},
+		{"(-1) ** 200000001", "-1"},
+		{"0 ** (2 ** 70)", "0"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input, WithBigInts())
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+	testFloatObject(t, testEval("(2 ** 70) ** -1", WithBigInts()), math.Pow(2, -70))
+}
+
+func TestOperatorErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"5 % 0", "modulo by zero: 5 % 0"},
+		{"1 << -1", "negative shift count: 1 << -1"},
+		{"1 >> -2", "negative shift count: 1 >> -2"},
+		{"1.5 & 1", "unknown operator: FLOAT & INTEGER"},
+		{"~1.5", "unknown operator: ~FLOAT"},
+		{"~true", "unknown operator: ~BOOLEAN"},
+		{"true ** 2", "type mismatch: BOOLEAN ** INTEGER"},
+		{`"a" % "b"`, "unknown operator: STRING % STRING"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+
+	bigTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"(2 ** 70) % 0", "modulo by zero: 1180591620717411303424 % 0"},
+		{"(2 ** 70) << -1", "negative shift count: 1180591620717411303424 << -1"},
+		{"1 << (2 ** 70)", "shift count too large: 1 << 1180591620717411303424"},
+		{"3 ** 200000000", "exponent too large: 3 ** 200000000"},
+		{"2 ** (2 ** 70)", "exponent too large: 2 ** 1180591620717411303424"},
+	}
+	for _, tt := range bigTests {
+		evaluated := testEval(tt.input, WithBigInts())
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	evaluated := testEval("[1, 2 * 2, 3 + 3]")
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(result.Elements) != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d", len(result.Elements))
+	}
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+	if result.Inspect() != "[1, 4, 6]" {
+		t.Errorf("Inspect() wrong. got=%q", result.Inspect())
+	}
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"[[1, 2], [3, 4]][1][0]", 3},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestArraySliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4][1:]", "[2, 3, 4]"},
+		{"[1, 2, 3, 4][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4][:]", "[1, 2, 3, 4]"},
+		{"[1, 2, 3, 4][-2:]", "[3, 4]"},
+		{"[1, 2, 3, 4][:-1]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4][3:1]", "[]"},
+		{"[1, 2, 3, 4][-10:10]", "[1, 2, 3, 4]"},
+		{"[1, 2, 3, 4][1:3][0:1]", "[2]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if _, ok := evaluated.(*object.Array); !ok {
+			t.Errorf("object is not Array for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong slice for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestArrayErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"[1, 2, 3][3]", "index out of range: 3 with length 3"},
+		{"[1, 2, 3][-4]", "index out of range: -4 with length 3"},
+		{"[][0]", "index out of range: 0 with length 0"},
+		{`[1][true]`, "array index must be INTEGER, got BOOLEAN"},
+		{"1[0]", "index operator not supported: INTEGER"},
+		{"1[0:1]", "slice operator not supported: INTEGER"},
+		{`[1][0:"a"]`, "slice index must be INTEGER, got STRING"},
+		{"[1, foobar]", "identifier not found: foobar"},
+		{"[1][foobar]", "identifier not found: foobar"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		TRUE.HashKey():                             5,
+		FALSE.HashKey():                            6,
+	}
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+		}
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+	if result.Inspect() != "{one: 1, two: 2, three: 3, 4: 4, true: 5, false: 6}" {
+		t.Errorf("Inspect() wrong. got=%q", result.Inspect())
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+		{`{"a": 1, "a": 2}["a"]`, 2},
+		{`{"a": {"b": 3}}["a"]["b"]`, 3},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashBigIntegerKeys(t *testing.T) {
+	input := `{99999999999999999999: 1, -99999999999999999999: 2}[-99999999999999999999]`
+	testIntegerObject(t, testEval(input, WithBigInts()), 2)
+	// FNV-1a of 2 ** 64 is 5952119183343170476.
+	testNullObject(t, testEval(`{2 ** 64: "big"}[5952119183343170476]`, WithBigInts()))
+	testIntegerObject(t, testEval(`len({2 ** 64: "big", 5952119183343170476: "small"})`, WithBigInts()), 2)
+}
+
+func TestHashErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`{"name": "Monkey"}[[1]]`, "unusable as hash key: ARRAY"},
+		{`{[1, 2]: 1}`, "unusable as hash key: ARRAY"},
+		{`{{}: 1}`, "unusable as hash key: HASH"},
+		{`{1.5: 1}`, "unusable as hash key: FLOAT"},
+		{`{"a": foobar}`, "identifier not found: foobar"},
+		{`{foobar: 1}`, "identifier not found: foobar"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestFunctionObject(t *testing.T) {
+	evaluated := testEval("fn(x) { x + 2; };")
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(fn.Parameters) != 1 {
+		t.Fatalf("function has wrong parameters. Parameters=%+v", fn.Parameters)
+	}
+	if fn.Parameters[0].String() != "x" {
+		t.Fatalf("parameter is not 'x'. got=%q", fn.Parameters[0])
+	}
+	if fn.Body.String() != "(x + 2)" {
+		t.Fatalf("body is not %q. got=%q", "(x + 2)", fn.Body.String())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{"let f = fn() { return 1; 2 }; f() + 10", 11},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestClosures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`
+		let newAdder = fn(x) { fn(y) { x + y } };
+		let addTwo = newAdder(2);
+		addTwo(2);`, 4},
+		{`
+		let adder = fn(x) { fn(y) { fn(z) { x + y + z } } };
+		adder(1)(2)(3);`, 6},
+		{`
+		let x = 10;
+		let f = fn() { x };
+		let g = fn(x) { f() };
+		g(20);`, 10},
+		{`
+		let compose = fn(f, g) { fn(x) { g(f(x)) } };
+		let inc = fn(x) { x + 1 };
+		let double = fn(x) { x * 2 };
+		compose(inc, double)(5);`, 12},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	input := `
+	let counter = fn(n) {
+		{"value": n, "next": fn() { counter(n + 1) }}
+	};
+	let first = counter(0);
+	let second = first["next"]();
+	let third = second["next"]();
+	first["value"] * 100 + second["value"] * 10 + third["value"];`
+	testIntegerObject(t, testEval(input), 12)
+}
+
+func TestRecursion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`
+		let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } };
+		fact(10);`, 3628800},
+		{`
+		let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) };
+		fib(15);`, 610},
+		{`
+		let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+		let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+		if (isEven(10) && isOdd(7)) { 1 } else { 0 };`, 1},
+		{`
+		let outer = fn() {
+			let ping = fn(n) { if (n == 0) { 0 } else { pong(n - 1) + 1 } };
+			let pong = fn(n) { if (n == 0) { 0 } else { ping(n - 1) + 1 } };
+			ping(9)
+		};
+		outer();`, 9},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStackOverflow(t *testing.T) {
+	evaluated := testEval("let f = fn(n) { f(n + 1) }; f(0)")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	expected := fmt.Sprintf("stack overflow: more than %d nested calls", maxCallDepth)
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+	}
+	if len(errObj.Stack) != maxCallDepth || errObj.Stack[0].Function != "f" {
+		t.Errorf("wrong stack. expected %d frames of f, got=%d", maxCallDepth, len(errObj.Stack))
+	}
+	caught := testEval("let f = fn(n) { f(n + 1) }; try { f(0) } catch (e) { e[\"message\"] }")
+	if caught.Inspect() != expected {
+		t.Errorf("stack overflow not caught. got=%s", caught.Inspect())
+	}
+	// The depth is released as calls return, so the interpreter stays usable.
+	testIntegerObject(t, testEval("let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(9000); f(9000); 1"), 1)
+}
+
+func TestBlockScoping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let x = 1; if (true) { let x = 2; }; x", 1},
+		{"let x = 1; if (true) { let x = 2; x }", 2},
+		{"let x = 1; if (true) { let y = x + 1; y }", 2},
+		{"let x = 1; let f = fn() { let x = 5; x }; f() + x", 6},
+		{"let x = 1; if (true) { if (true) { let x = 3; }; x }", 1},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+
+	evaluated := testEval("if (true) { let y = 2; }; y")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "identifier not found: y" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"5()", "not a function: INTEGER"},
+		{"let f = fn(x) { x }; f()", "wrong number of arguments: want=1, got=0"},
+		{"let f = fn() { 1 }; f(1, 2)", "wrong number of arguments: want=0, got=2"},
+		{"let f = fn(x) { x }; f(foobar)", "identifier not found: foobar"},
+		{"let f = fn() { true + 1 }; f(); 5", "type mismatch: BOOLEAN + INTEGER"},
+		{"fn(x) { y }(1)", "identifier not found: y"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("héllo")`, 5},
+		{`len([1, 2, 3])`, 3},
+		{`len({"a": 1, "b": 2})`, 2},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("one", "two")`, "wrong number of arguments to `len`: want=1, got=2"},
+		{`first([1, 2, 3])`, 1},
+		{`first([])`, nil},
+		{`first(1)`, "argument to `first` not supported, got INTEGER"},
+		{`last([1, 2, 3])`, 3},
+		{`last([])`, nil},
+		{`rest([1, 2, 3])`, "[2, 3]"},
+		{`rest([])`, nil},
+		{`push([], 1)`, "[1]"},
+		{`let a = [1]; push(a, 2); a`, "[1]"},
+		{`push(1, 1)`, "argument to `push` not supported, got INTEGER"},
+		{`push([1])`, "wrong number of arguments to `push`: want=2, got=1"},
+		{`type(1)`, "INTEGER"},
+		{`type("a")`, "STRING"},
+		{`type(len)`, "BUILTIN"},
+		{`type(fn() {})`, "FUNCTION"},
+		{`str(12)`, "12"},
+		{`str(1.5)`, "1.5"},
+		{`str([1, true])`, "[1, true]"},
+		{`str("a") + str(1)`, "a1"},
+		{`int("42")`, 42},
+		{`int(" -7 ")`, -7},
+		{`int("0xff")`, 255},
+		{`int(3.9)`, 3},
+		{`int(-3.9)`, -3},
+		{`int(true)`, 1},
+		{`int(7)`, 7},
+		{`int("0o755")`, 493},
+		{`int("0")`, 0},
+		{`int("-0")`, 0},
+		{`int("abc")`, `cannot convert "abc" to integer`},
+		{`int("010")`, `cannot convert "010" to integer: leading zero`},
+		{`int("-0755")`, `cannot convert "-0755" to integer: leading zero`},
+		{`int(1e30)`, "cannot convert 1e+30 to integer: overflows int64"},
+		{`int([])`, "argument to `int` not supported, got ARRAY"},
+		{`let len = fn(x) { 99 }; len([])`, 99},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, expected, errObj.Message)
+				}
+				continue
+			}
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestBuiltinIntWithBigInts(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`int("99999999999999999999")`, "99999999999999999999"},
+		{`int(1e20)`, "100000000000000000000"},
+		{`int("12")`, "12"},
+		{`int("099999999999999999999")`, `ERROR: cannot convert "099999999999999999999" to integer: leading zero`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input, WithBigInts())
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestPuts(t *testing.T) {
+	var out bytes.Buffer
+	evaluated := testEval(`puts("hello", 1, [2]); puts()`, WithOutput(&out))
+	testNullObject(t, evaluated)
+	if out.String() != "hello\n1\n[2]\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
+func TestBuiltinRegistry(t *testing.T) {
+	double := &object.Builtin{
+		Name:    "double",
+		MinArgs: 1,
+		MaxArgs: 1,
+		Fn: func(args ...object.Object) object.Object {
+			return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+		},
+	}
+	sum := &object.Builtin{
+		Name:    "sum",
+		MinArgs: 1,
+		MaxArgs: -1,
+		Fn: func(args ...object.Object) object.Object {
+			total := int64(0)
+			for _, arg := range args {
+				total += arg.(*object.Integer).Value
+			}
+			return &object.Integer{Value: total}
+		},
+	}
+	pair := &object.Builtin{Name: "pair", MinArgs: 1, MaxArgs: 2, Fn: builtinType}
+	opts := []Option{WithBuiltin(double), WithBuiltin(sum), WithBuiltin(pair), WithoutBuiltins("puts")}
+
+	testIntegerObject(t, testEval("double(21)", opts...), 42)
+	testIntegerObject(t, testEval("sum(1, 2, 3)", opts...), 6)
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"sum()", "wrong number of arguments to `sum`: want=1 or more, got=0"},
+		{"pair(1, 2, 3)", "wrong number of arguments to `pair`: want=1 to 2, got=3"},
+		{`puts("x")`, "identifier not found: puts"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input, opts...)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+
+	in := New()
+	in.Unregister("len")
+	if _, ok := in.Builtin("len"); ok {
+		t.Errorf("len still registered after Unregister")
+	}
+	if _, ok := New().Builtin("len"); !ok {
+		t.Errorf("Unregister on one interpreter affected another")
+	}
+}
+
+func TestErrorSpans(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedStart int
+		expectedEnd   int
+	}{
+		{"5 + true;", 0, 8},
+		{"let x = 1;\nx + foobar", 15, 21},
+		{"if (1 > 0) { -true }", 13, 18},
+		{"[1, 2][5]", 0, 9},
+		{`len(1, 2)`, 0, 9},
+		{"let f = fn(x) { x / 0 }; f(1)", 16, 21},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Span.Start.Offset != tt.expectedStart || errObj.Span.End.Offset != tt.expectedEnd {
+			t.Errorf("wrong span for %q. expected=%d-%d, got=%d-%d", tt.input, tt.expectedStart, tt.expectedEnd, errObj.Span.Start.Offset, errObj.Span.End.Offset)
+		}
+	}
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `let add = fn(a, b) {
+	a + b
+};
+let twice = fn(x) {
+	add(x, true)
+};
+let apply = fn(f) { f(1) };
+apply(twice);`
+	program := parser.New(lexer.New(input, lexer.WithFilename("trace.mk"))).ParseProgram()
+	evaluated := New().Eval(program, object.NewEnvironment())
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	expectedStack := []struct {
+		function string
+		call     string
+	}{
+		{"add", "trace.mk:5:2"},
+		{"twice", "trace.mk:7:21"},
+		{"apply", "trace.mk:8:1"},
+	}
+	if len(errObj.Stack) != len(expectedStack) {
+		t.Fatalf("wrong stack depth. expected=%d, got=%d (%+v)", len(expectedStack), len(errObj.Stack), errObj.Stack)
+	}
+	for i, frame := range expectedStack {
+		if errObj.Stack[i].Function != frame.function || errObj.Stack[i].Call.String() != frame.call {
+			t.Errorf("stack[%d] wrong. expected=%s at %s, got=%s at %s", i, frame.function, frame.call, errObj.Stack[i].Function, errObj.Stack[i].Call)
+		}
+	}
+	expected := `runtime error: type mismatch: INTEGER + BOOLEAN
+
+add(...)
+	trace.mk:2:2
+
+twice(...)
+	trace.mk:5:2
+
+apply(...)
+	trace.mk:7:21
+
+<main>
+	trace.mk:8:1
+`
+	if errObj.Trace() != expected {
+		t.Errorf("wrong trace.\nexpected:\n%s\ngot:\n%s", expected, errObj.Trace())
+	}
+}
+
+func TestAnonymousFunctionTrace(t *testing.T) {
+	evaluated := testEval("fn() { 1 + fn() { -true }() }()")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	expected := []object.Frame{
+		{Function: "<anonymous>", Call: token.Position{Offset: 11, Line: 1, Column: 12}},
+		{Function: "<anonymous>", Call: token.Position{Offset: 0, Line: 1, Column: 1}},
+	}
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong stack depth. got=%+v", errObj.Stack)
+	}
+	for i := range expected {
+		if errObj.Stack[i] != expected[i] {
+			t.Errorf("stack[%d] wrong. expected=%+v, got=%+v", i, expected[i], errObj.Stack[i])
+		}
+	}
+}
+
+func TestTryCatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`try { throw "boom"; 1 } catch (e) { 2 }`, 2},
+		{`try { throw "boom" } catch (e) { e["message"] }`, "boom"},
+		{`try { 1 + true } catch (e) { e["message"] }`, "type mismatch: INTEGER + BOOLEAN"},
+		{`try { len(1) } catch (e) { e["message"] }`, "argument to `len` not supported, got INTEGER"},
+		{`try { throw 42 } catch (e) { e["value"] }`, 42},
+		{`try { throw 42 } catch (e) { e["message"] }`, "42"},
+		{`try { [1][5] } catch (e) { e["value"] }`, nil},
+		{`try { throw {"message": "custom", "code": 7} } catch (e) { e["value"]["code"] }`, 7},
+		{`try { throw {"message": "custom"} } catch (e) { e["message"] }`, "custom"},
+		{`try { try { throw "inner" } catch (e) { throw e } } catch (e) { e["message"] }`, "inner"},
+		{`try { try { throw "inner" } finally { 1 } } catch (e) { e["message"] }`, "inner"},
+		{`let e = 1; try { throw "x" } catch (e) { 2 }; e`, 1},
+		{`let f = fn() { throw "deep" }; let g = fn() { f() }; try { g() } catch (e) { len(e["stack"]) }`, 3},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("wrong value for %q. expected=%q, got=%q", tt.input, expected, str.Value)
+			}
+		case nil:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestTryFinally(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		output   string
+	}{
+		{`try { 1 } finally { puts("f") }`, 1, "f\n"},
+		{`try { throw "x" } catch (e) { 2 } finally { puts("f") }`, 2, "f\n"},
+		{`let f = fn() { try { return 1; } finally { puts("f") }; 2 }; f()`, 1, "f\n"},
+		{`let f = fn() { try { throw "x" } catch (e) { return 2; } finally { puts("f") }; 3 }; f()`, 2, "f\n"},
+		{`let f = fn() { try { return 1; } finally { return 2; } }; f()`, 2, ""},
+		{`let f = fn() { try { throw "x" } finally { return 3; } }; f()`, 3, ""},
+		{`let f = fn() { try { 1 } finally { 5 }; 4 }; f()`, 4, ""},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		testIntegerObject(t, testEval(tt.input, WithOutput(&out)), tt.expected)
+		if out.String() != tt.output {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.output, out.String())
+		}
+	}
+}
+
+func TestUncaughtThrow(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`throw "boom"; 1`, "boom"},
+		{`let f = fn() { throw "boom" }; f(); 1`, "boom"},
+		{`try { throw "a" } finally { 1 }`, "a"},
+		{`try { 1 } catch (e) { 2 } finally { throw "late" }`, "late"},
+		{`try { throw "a" } catch (e) { throw "b" }`, "b"},
+		{`try { throw "a" } catch (e) { 1 } finally { foobar }`, "identifier not found: foobar"},
+		{`throw foobar`, "identifier not found: foobar"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestCaughtErrorStack(t *testing.T) {
+	input := `let fail = fn() { throw "boom" };
+let run = fn() { fail() };
+try { run() } catch (e) { e["stack"] }`
+	program := parser.New(lexer.New(input, lexer.WithFilename("stack.mk"))).ParseProgram()
+	evaluated := New().Eval(program, object.NewEnvironment())
+	expected := "[fail(...) at stack.mk:1:19, run(...) at stack.mk:2:18, <main> at stack.mk:3:7]"
+	if evaluated.Inspect() != expected {
+		t.Errorf("wrong stack.\nexpected=%s\ngot=%s", expected, evaluated.Inspect())
+	}
+}
+
+func TestWhileLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`while (false) { puts("never") }`, ""},
+		{`let f = fn(n) { while (true) { if (n > 2) { return n; } puts(n); break; } }; f(1)`, "1\n"},
+		{`let count = fn(n) { while (n > 0) { puts(n); return count(n - 1); } }; count(3)`, "3\n2\n1\n"},
+		{`for (i in range(5)) { while (true) { puts(i); break; } if (i == 1) { break } }`, "0\n1\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		evaluated := testEval(tt.input, WithOutput(&out))
+		if isError(evaluated) {
+			t.Errorf("unexpected error for %q: %s", tt.input, evaluated.Inspect())
+			continue
+		}
+		if out.String() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestForLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`for (x in [1, 2, 3]) { puts(x) }`, "1\n2\n3\n"},
+		{`for (x in []) { puts(x) }`, ""},
+		{`for (k in {"b": 1, "a": 2, 3: 3}) { puts(k) }`, "b\na\n3\n"},
+		{`let h = {"x": 10}; for (k in h) { puts(h[k]) }`, "10\n"},
+		{`for (i in range(3)) { puts(i) }`, "0\n1\n2\n"},
+		{`for (i in range(2, 5)) { puts(i) }`, "2\n3\n4\n"},
+		{`for (i in range(10, 0, -4)) { puts(i) }`, "10\n6\n2\n"},
+		{`for (i in range(3, 3)) { puts(i) }`, ""},
+		{`for (i in range(5)) { if (i == 3) { break; } puts(i) }`, "0\n1\n2\n"},
+		{`for (i in range(5)) { if (i % 2 == 0) { continue; } puts(i) }`, "1\n3\n"},
+		{`for (i in range(2)) { for (j in range(3)) { if (j == 1) { break } puts([i, j]) } }`, "[0, 0]\n[1, 0]\n"},
+		{`for (i in range(3)) { try { if (i == 1) { continue } puts(i) } finally { puts("f") } }`, "0\nf\nf\n2\nf\n"},
+		{`for (i in range(2)) { let g = fn() { i }; puts(g()) }`, "0\n1\n"},
+		{`let i = 99; for (i in range(1)) { }; puts(i)`, "99\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		evaluated := testEval(tt.input, WithOutput(&out))
+		if isError(evaluated) {
+			t.Errorf("unexpected error for %q: %s", tt.input, evaluated.Inspect())
+			continue
+		}
+		if out.String() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestLoopControlInExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let i = 0; while (true) { i += 1; let y = if (i > 3) { break; } else { i }; puts(y) }`, "1\n2\n3\n"},
+		{`let s = []; for (x in [1, 2, 3]) { s = push(s, if (x == 2) { continue; } else { x }) }; puts(s)`, "[1, 3]\n"},
+		{`let s = 0; for (x in [1, 2]) { s = s + (if (true) { continue; } else { 1 }) }; puts(s)`, "0\n"},
+		{`for (x in [1, 2]) { puts(-(if (x == 1) { continue; } else { x })) }`, "-2\n"},
+		{`for (x in [1, 2]) { puts([if (x == 1) { continue; } else { x }]) }`, "[2]\n"},
+		{`for (x in [1, 2]) { puts({"k": if (x == 1) { continue; } else { x }}) }`, "{k: 2}\n"},
+		{`let a = [0]; for (x in [1, 2]) { a[0] = if (x == 2) { break; } else { x } }; puts(a)`, "[1]\n"},
+		{`for (x in [1, 2]) { if (if (x == 1) { continue; } else { true }) { puts(x) } }`, "2\n"},
+		{`for (x in [1, 2]) { return if (x == 1) { continue; } else { x } }; puts(0)`, ""},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		evaluated := testEval(tt.input, WithOutput(&out))
+		if isError(evaluated) {
+			t.Errorf("unexpected error for %q: %s", tt.input, evaluated.Inspect())
+			continue
+		}
+		if out.String() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestLoopResults(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`for (x in [1]) { x }`, nil},
+		{`while (false) { 1 }`, nil},
+		{`let find = fn(xs, want) { for (x in xs) { if (x == want) { return true; } } false }; find([1, 2, 3], 2)`, true},
+		{`let find = fn(xs, want) { for (x in xs) { if (x == want) { return true; } } false }; find([1, 2, 3], 5)`, false},
+		{`let sum = fn(n) { for (i in range(n)) { if (i == n - 1) { return i * 2; } } }; sum(4)`, 6},
+		{`len(range(10, 0, -3))`, 4},
+		{`len(range(0, 10, -1))`, 0},
+		{`type(range(3))`, "RANGE"},
+		{`range(1, 10, 2)`, "range(1, 10, 2)"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case nil:
+			testNullObject(t, evaluated)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"for (x in 5) { }", "cannot iterate over INTEGER"},
+		{"for (x in foobar) { }", "identifier not found: foobar"},
+		{"while (foobar) { }", "identifier not found: foobar"},
+		{"for (x in [1, 2]) { x + true }", "type mismatch: INTEGER + BOOLEAN"},
+		{"range(0, 5, 0)", "range step must not be zero"},
+		{`range("a")`, "argument to `range` not supported, got STRING"},
+		{"range()", "wrong number of arguments to `range`: want=1 to 3, got=0"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+	evaluated := testEval("range(99999999999999999999)", WithBigInts())
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != "argument to `range` overflows int64: 99999999999999999999" {
+		t.Errorf("wrong result for big range bound. got=%s", evaluated.Inspect())
+	}
+}
+
+func TestLongLoopDoesNotGrowStack(t *testing.T) {
+	var out bytes.Buffer
+	testEval(`let total = fn(n) { for (i in range(n)) { if (i == n - 1) { puts(i) } } }; total(200000)`, WithOutput(&out))
+	if out.String() != "199999\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
+func TestAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; x = 2; x", 2},
+		{"let x = 1; x = x + 1", 2},
+		{"let x = 10; x += 5; x", 15},
+		{"let x = 10; x -= 5; x", 5},
+		{"let x = 10; x *= 5; x", 50},
+		{"let x = 10; x /= 4; x", 2},
+		{"let x = 1.5; x *= 2; x", 3.0},
+		{`let s = "a"; s += "b"; s`, "ab"},
+		{"let a = 1; let b = 2; a = b = 7; a + b", 14},
+		{"let x = 1; if (true) { x = 5 }; x", 5},
+		{"let x = 1; if (true) { let x = 2; x = 3 }; x", 1},
+		{"let arr = [1, 2, 3]; arr[0] = 10; arr", "[10, 2, 3]"},
+		{"let arr = [1, 2, 3]; arr[-1] += 10; arr", "[1, 2, 13]"},
+		{"let arr = [[1], [2]]; arr[1][0] = 5; arr", "[[1], [5]]"},
+		{`let h = {"a": 1}; h["a"] = 2; h["b"] = 3; h`, "{a: 2, b: 3}"},
+		{`let h = {"n": 1}; h["n"] *= 7; h["n"]`, 7},
+		{`let a = [1]; let b = a; b[0] = 9; a[0]`, 9},
+		{"let i = 0; let total = 0; while (i < 5) { total += i; i += 1; }; total", 10},
+		{"let arr = [1, 2, 3]; for (i in range(len(arr))) { arr[i] *= 2 }; arr", "[2, 4, 6]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestSelfReferencingContainers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1]; a[0] = a", "[[...]]"},
+		{"let a = [1, 2]; a[1] = a; a", "[1, [...]]"},
+		{`let h = {"n": 1}; h["self"] = h; h`, "{n: 1, self: {...}}"},
+		{`let a = [0]; let h = {"a": a}; a[0] = h; a`, "[{a: [...]}]"},
+		{"let b = [1]; [b, b, [b]]", "[[1], [1], [[1]]]"},
+		{"let a = [1]; a[0] = a; str(a)", "[[...]]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMutableCounters(t *testing.T) {
+	input := `
+	let newCounter = fn() {
+		let count = 0;
+		fn() { count += 1 }
+	};
+	let a = newCounter();
+	let b = newCounter();
+	a(); a(); b();
+	a() * 10 + b()`
+	testIntegerObject(t, testEval(input), 32)
+}
+
+func TestAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"x = 1", "assignment to undeclared variable: x"},
+		{"let f = fn() { y = 2 }; f()", "assignment to undeclared variable: y"},
+		{"len = 1", "assignment to undeclared variable: len"},
+		{"x += 1", "identifier not found: x"},
+		{"let x = 1; x += true", "type mismatch: INTEGER + BOOLEAN"},
+		{"let x = 1; x /= 0", "division by zero: 1 / 0"},
+		{"let x = 1; x = foobar", "identifier not found: foobar"},
+		{"let arr = [1]; arr[1] = 2", "index out of range: 1 with length 1"},
+		{`let arr = [1]; arr["a"] = 2`, "array index must be INTEGER, got STRING"},
+		{"let h = {}; h[[1]] = 2", "unusable as hash key: ARRAY"},
+		{`let h = {}; h["a"] += 1`, "type mismatch: NULL + INTEGER"},
+		{`let s = "abc"; s[0] = "x"`, "index assignment not supported: STRING"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestElseIfExpressions(t *testing.T) {
+	classify := `let classify = fn(n) {
+		if (n < 0) { "negative" }
+		else if (n == 0) { "zero" }
+		else if (n < 10) { "small" }
+		else if (n < 100) { "medium" }
+		else { "large" }
+	};`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{classify + "classify(-5)", "negative"},
+		{classify + "classify(0)", "zero"},
+		{classify + "classify(7)", "small"},
+		{classify + "classify(42)", "medium"},
+		{classify + "classify(1000)", "large"},
+		{"if (false) { 1 } else if (false) { 2 }", nil},
+		{"if (false) { 1 } else if (true) { 2 } else if (true) { 3 }", 2},
+		{"let f = fn(x) { if (x == 1) { return 10; } else if (x == 2) { return 20; } 30 }; f(2)", 20},
+		{"let f = fn(x) { if (x == 1) { return 10; } else if (x == 2) { return 20; } 30 }; f(3)", 30},
+		{"let x = 1; if (false) { } else if (true) { let x = 2; }; x", 1},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("wrong value. expected=%q, got=%q", expected, str.Value)
+			}
+		}
+	}
+}
+
+func TestElseIfShortCircuits(t *testing.T) {
+	var out bytes.Buffer
+	input := `let check = fn(name, result) { puts(name); result };
+	if (check("a", false)) { 1 } else if (check("b", true)) { 2 } else if (check("c", true)) { 3 }`
+	testIntegerObject(t, testEval(input, WithOutput(&out)), 2)
+	if out.String() != "a\nb\n" {
+		t.Errorf("wrong conditions evaluated. got=%q", out.String())
+	}
+
+	evaluated := testEval("if (false) { 1 } else if (foobar) { 2 } else { 3 }")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "identifier not found: foobar" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
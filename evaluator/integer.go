@@ -0,0 +1,222 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"monkey-lang/ast"
+	"monkey-lang/object"
+)
+
+func (in *Interpreter) evalIntegerLiteral(node *ast.IntegerLiteral) object.Object {
+	if node.Big == nil {
+		return &object.Integer{Value: node.Value}
+	}
+	if !in.bigInts {
+		return newError("integer literal %s overflows int64", node.Token.Literal)
+	}
+	return &object.BigInteger{Value: node.Big}
+}
+
+// evalIntegerInfixExpression works on plain int64 values while both operands
+// fit, which is the common case. Only when big integers are enabled and a
+// result would overflow does it switch to math/big.
+func (in *Interpreter) evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	l, lok := left.(*object.Integer)
+	r, rok := right.(*object.Integer)
+	if !lok || !rok {
+		return evalBigIntegerInfixExpression(operator, toBig(left), toBig(right))
+	}
+	leftVal, rightVal := l.Value, r.Value
+	switch operator {
+	case "+":
+		sum := leftVal + rightVal
+		if in.bigInts && (leftVal >= 0) == (rightVal >= 0) && (sum >= 0) != (leftVal >= 0) {
+			break
+		}
+		return &object.Integer{Value: sum}
+	case "-":
+		diff := leftVal - rightVal
+		if in.bigInts && (leftVal >= 0) != (rightVal >= 0) && (diff >= 0) != (leftVal >= 0) {
+			break
+		}
+		return &object.Integer{Value: diff}
+	case "*":
+		if in.bigInts && mulOverflows(leftVal, rightVal) {
+			break
+		}
+		return &object.Integer{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %d / %d", leftVal, rightVal)
+		}
+		if in.bigInts && leftVal == math.MinInt64 && rightVal == -1 {
+			break
+		}
+		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero: %d %% %d", leftVal, rightVal)
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "**":
+		if rightVal < 0 {
+			return &object.Float{Value: math.Pow(float64(leftVal), float64(rightVal))}
+		}
+		power, ok := powInt(leftVal, rightVal)
+		if !ok && in.bigInts {
+			break
+		}
+		return &object.Integer{Value: power}
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+	case "<<":
+		if rightVal < 0 {
+			return newError("negative shift count: %d << %d", leftVal, rightVal)
+		}
+		shifted := leftVal << rightVal
+		if in.bigInts && leftVal != 0 && (rightVal >= 63 || shifted>>rightVal != leftVal) {
+			break
+		}
+		return &object.Integer{Value: shifted}
+	case ">>":
+		if rightVal < 0 {
+			return newError("negative shift count: %d >> %d", leftVal, rightVal)
+		}
+		return &object.Integer{Value: leftVal >> rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+	return evalBigIntegerInfixExpression(operator, toBig(left), toBig(right))
+}
+
+func evalBigIntegerInfixExpression(operator string, leftVal, rightVal *big.Int) object.Object {
+	switch operator {
+	case "+":
+		return normalizeBig(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return normalizeBig(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return normalizeBig(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero: %s / %s", leftVal, rightVal)
+		}
+		return normalizeBig(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("modulo by zero: %s %% %s", leftVal, rightVal)
+		}
+		return normalizeBig(new(big.Int).Rem(leftVal, rightVal))
+	case "**":
+		if rightVal.Sign() < 0 {
+			return &object.Float{Value: math.Pow(bigToFloat(leftVal), bigToFloat(rightVal))}
+		}
+		if new(big.Int).Abs(leftVal).Cmp(big.NewInt(1)) > 0 &&
+			(!rightVal.IsUint64() || rightVal.Uint64() > maxBigShift || uint64(leftVal.BitLen())*rightVal.Uint64() > maxBigShift) {
+			return newError("exponent too large: %s ** %s", leftVal, rightVal)
+		}
+		return normalizeBig(new(big.Int).Exp(leftVal, rightVal, nil))
+	case "&":
+		return normalizeBig(new(big.Int).And(leftVal, rightVal))
+	case "|":
+		return normalizeBig(new(big.Int).Or(leftVal, rightVal))
+	case "^":
+		return normalizeBig(new(big.Int).Xor(leftVal, rightVal))
+	case "<<", ">>":
+		if rightVal.Sign() < 0 {
+			return newError("negative shift count: %s %s %s", leftVal, operator, rightVal)
+		}
+		if !rightVal.IsUint64() || rightVal.Uint64() > maxBigShift {
+			return newError("shift count too large: %s %s %s", leftVal, operator, rightVal)
+		}
+		if operator == "<<" {
+			return normalizeBig(new(big.Int).Lsh(leftVal, uint(rightVal.Uint64())))
+		}
+		return normalizeBig(new(big.Int).Rsh(leftVal, uint(rightVal.Uint64())))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", object.INTEGER_OBJ, operator, object.INTEGER_OBJ)
+	}
+}
+
+// maxBigShift bounds shift counts on big integers, since a left shift
+// allocates one bit per step, and likewise the estimated bit length of a
+// power.
+const maxBigShift = 1 << 24
+
+// powInt computes base**exp by squaring and reports false if the result
+// overflowed, in which case the returned value has wrapped.
+func powInt(base, exp int64) (int64, bool) {
+	result, ok := int64(1), true
+	for exp > 0 {
+		if exp&1 == 1 {
+			ok = ok && !mulOverflows(result, base)
+			result *= base
+		}
+		exp >>= 1
+		if exp > 0 {
+			ok = ok && !mulOverflows(base, base)
+			base *= base
+		}
+	}
+	return result, ok
+}
+
+func mulOverflows(a, b int64) bool {
+	if a == 0 || b == 0 {
+		return false
+	}
+	product := a * b
+	return product/a != b || a == -1 && b == math.MinInt64 || b == -1 && a == math.MinInt64
+}
+
+// normalizeBig turns results that fit in an int64 back into plain Integers so
+// later arithmetic takes the fast path again.
+func normalizeBig(value *big.Int) object.Object {
+	if value.IsInt64() {
+		return &object.Integer{Value: value.Int64()}
+	}
+	return &object.BigInteger{Value: value}
+}
+
+func toBig(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInteger:
+		return obj.Value
+	}
+	return new(big.Int)
+}
+
+func bigToFloat(value *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(value).Float64()
+	return f
+}
@@ -1,36 +1,154 @@
 package lexer
 
 import (
+	"fmt"
+	"monkey-lang/diag"
 	"monkey-lang/token"
+	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
-type Lexer struct {
-	input        string
-	position     int
-	readPosition int
-	ch           rune
-}
+type (
+	// Lexer walks the input one rune at a time. ch is the current rune, which
+	// starts at byte offset pos.Offset and is width bytes long. input holds the
+	// source from byte offset base on; it is the whole source unless the lexer
+	// streams from a reader, in which case everything before mark, the start of
+	// the current token, may be dropped. See NewReader.
+	Lexer struct {
+		input       string
+		base        int
+		mark        int
+		ch          rune
+		width       int
+		pos         token.Position
+		tokPos      token.Position
+		diagnostics []*diag.Diagnostic
+		comments    bool
+		kebabCase   bool
+		stream      *stream
+	}
+
+	Option func(*Lexer)
+)
+
+const eof = -1
 
-func New(input string) *Lexer {
+func New(input string, opts ...Option) *Lexer {
 	l := &Lexer{input: input}
-	l.readChar()
+	return l.init(opts)
+}
+
+func (l *Lexer) init(opts []Option) *Lexer {
+	l.pos = token.Position{Line: 1, Column: 1}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.ch, l.width = l.decode(0)
 	return l
 }
 
+// WithFilename sets the file name reported in token positions.
+func WithFilename(filename string) Option {
+	return func(l *Lexer) {
+		l.pos.Filename = filename
+	}
+}
+
+// WithComments makes the lexer emit comments as token.COMMENT instead of
+// skipping them, for tools that need to keep them such as formatters.
+func WithComments() Option {
+	return func(l *Lexer) {
+		l.comments = true
+	}
+}
+
+// WithKebabCase lets identifiers contain dashes, as in `max-value`. A dash only
+// joins when it sits between a letter and another letter, so `x-1` and `x -y`
+// still lex as subtractions. Spaces are required around `-` between two names.
+func WithKebabCase() Option {
+	return func(l *Lexer) {
+		l.kebabCase = true
+	}
+}
+
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
+	if l.ch == eof {
+		return
+	}
+	l.pos.Offset += l.width
+	if l.ch == '\n' {
+		l.pos.Line++
+		l.pos.Column = 1
 	} else {
-		l.ch = []rune(l.input)[l.readPosition]
+		l.pos.Column++
 	}
-	l.position = l.readPosition
-	l.readPosition++
+	l.ch, l.width = l.decode(l.pos.Offset)
+}
+
+func (l *Lexer) decode(offset int) (rune, int) {
+	i := offset - l.base
+	for l.stream != nil && len(l.input)-i < utf8.UTFMax && l.fill() {
+		i = offset - l.base
+	}
+	if i >= len(l.input) {
+		return eof, 0
+	}
+	if b := l.input[i]; b < utf8.RuneSelf {
+		return rune(b), 1
+	}
+	return utf8.DecodeRuneInString(l.input[i:])
+}
+
+// slice returns the source between two byte offsets. Streamed input is copied
+// so tokens do not pin the lexer's buffer in memory.
+func (l *Lexer) slice(start, end int) string {
+	s := l.input[start-l.base : end-l.base]
+	if l.stream != nil {
+		s = strings.Clone(s)
+	}
+	return s
 }
 
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
-	l.skipWhitespace()
+	for {
+		l.skipWhitespace()
+		l.tokPos = l.pos
+		l.mark = l.pos.Offset
+		if l.ch != '/' || (l.peek() != '/' && l.peek() != '*') {
+			tok = l.readToken()
+			break
+		}
+		tok = l.readComment()
+		if l.comments {
+			break
+		}
+	}
+	tok.Pos = l.tokPos
+	tok.End = l.pos
+	return tok
+}
+
+func (l *Lexer) Diagnostics() []*diag.Diagnostic {
+	return l.diagnostics
+}
+
+func (l *Lexer) errorf(code diag.Code, start token.Position, format string, a ...interface{}) *diag.Diagnostic {
+	d := &diag.Diagnostic{
+		Severity: diag.Error,
+		Code:     code,
+		Span:     token.Span{Start: start, End: l.pos},
+		Message:  fmt.Sprintf(format, a...),
+		Found:    token.ILLEGAL,
+	}
+	l.diagnostics = append(l.diagnostics, d)
+	return d
+}
+
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
 	switch l.ch {
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
@@ -40,6 +158,12 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.RPAREN, l.ch)
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
+	case '[':
+		tok = newToken(token.LBRACKET, l.ch)
+	case ']':
+		tok = newToken(token.RBRACKET, l.ch)
 	case '=':
 		if l.peek() == '=' {
 			tok.Literal = "=="
@@ -49,9 +173,21 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peek() == '=' {
+			tok.Literal = "+="
+			tok.Type = token.PLUS_ASSIGN
+			l.readChar()
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peek() == '=' {
+			tok.Literal = "-="
+			tok.Type = token.MINUS_ASSIGN
+			l.readChar()
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peek() == '=' {
 			tok.Literal = "!="
@@ -61,86 +197,382 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		switch l.peek() {
+		case '*':
+			tok.Literal = "**"
+			tok.Type = token.POWER
+			l.readChar()
+		case '=':
+			tok.Literal = "*="
+			tok.Type = token.ASTERISK_ASSIGN
+			l.readChar()
+		default:
+			tok = newToken(token.ASTERISK, l.ch)
+		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
-	case '<':
 		if l.peek() == '=' {
+			tok.Literal = "/="
+			tok.Type = token.SLASH_ASSIGN
+			l.readChar()
+		} else {
+			tok = newToken(token.SLASH, l.ch)
+		}
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
+	case '<':
+		switch l.peek() {
+		case '=':
 			tok.Literal = "<="
 			tok.Type = token.LTE
 			l.readChar()
-		} else {
+		case '<':
+			tok.Literal = "<<"
+			tok.Type = token.SHL
+			l.readChar()
+		default:
 			tok = newToken(token.LT, l.ch)
 		}
 	case '>':
-		if l.peek() == '=' {
+		switch l.peek() {
+		case '=':
 			tok.Literal = ">="
 			tok.Type = token.GTE
 			l.readChar()
-		} else {
+		case '>':
+			tok.Literal = ">>"
+			tok.Type = token.SHR
+			l.readChar()
+		default:
 			tok = newToken(token.GT, l.ch)
 		}
+	case '&':
+		if l.peek() == '&' {
+			tok.Literal = "&&"
+			tok.Type = token.AND
+			l.readChar()
+		} else {
+			tok = newToken(token.BIT_AND, l.ch)
+		}
+	case '|':
+		if l.peek() == '|' {
+			tok.Literal = "||"
+			tok.Type = token.OR
+			l.readChar()
+		} else {
+			tok = newToken(token.BIT_OR, l.ch)
+		}
+	case '^':
+		tok = newToken(token.CARET, l.ch)
+	case '~':
+		tok = newToken(token.TILDE, l.ch)
 	case '{':
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
-	case 0:
+	case '"':
+		str, ok := l.readString()
+		if !ok {
+			tok.Literal = `"` + str
+			tok.Type = token.ILLEGAL
+			d := l.errorf(diag.UnterminatedString, l.tokPos, "unterminated string literal")
+			d.Hint = `close the string with '"'`
+			return tok
+		}
+		tok.Literal = str
+		tok.Type = token.STRING
+	case eof:
 		tok.Literal = ""
 		tok.Type = token.EOF
+		l.reportReadError()
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
+			tok.Literal, tok.Type = l.readNumber()
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			return l.readIllegal()
 		}
 	}
 	l.readChar()
 	return tok
 }
 
+func (l *Lexer) readIllegal() token.Token {
+	invalid := l.ch == utf8.RuneError && l.width == 1
+	tok := token.Token{Type: token.ILLEGAL, Literal: l.slice(l.pos.Offset, l.pos.Offset+l.width)}
+	l.readChar()
+	if invalid {
+		l.errorf(diag.IllegalCharacter, l.tokPos, "invalid UTF-8 encoding")
+	} else {
+		l.errorf(diag.IllegalCharacter, l.tokPos, "illegal character %q", tok.Literal)
+	}
+	return tok
+}
+
 func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
+// readString consumes a double-quoted string and returns its decoded value.
+// It stops on the closing quote, which is left for the caller to consume, and
+// reports false if the input ends first.
+func (l *Lexer) readString() (string, bool) {
+	var out strings.Builder
+	l.readChar()
+	for l.ch != '"' {
+		switch l.ch {
+		case eof:
+			return out.String(), false
+		case '\\':
+			l.readEscape(&out)
+		default:
+			out.WriteRune(l.ch)
+			l.readChar()
+		}
+	}
+	return out.String(), true
+}
+
+func (l *Lexer) readEscape(out *strings.Builder) {
+	start := l.pos
+	l.readChar()
+	switch l.ch {
+	case 'n':
+		out.WriteRune('\n')
+	case 't':
+		out.WriteRune('\t')
+	case 'r':
+		out.WriteRune('\r')
+	case '"':
+		out.WriteRune('"')
+	case '\\':
+		out.WriteRune('\\')
+	case 'u':
+		l.readChar()
+		l.readUnicodeEscape(start, out)
+		return
+	case eof:
+		return
+	default:
+		ch := l.ch
+		l.readChar()
+		d := l.errorf(diag.InvalidEscape, start, "unknown escape sequence \\%c", ch)
+		d.Hint = `valid escapes are \n, \t, \r, \", \\ and \u{...}`
+		return
+	}
+	l.readChar()
+}
+
+// readUnicodeEscape reads the `{XXXX}` part of a \u escape, 1 to 6 hex digits
+// naming a valid code point.
+func (l *Lexer) readUnicodeEscape(start token.Position, out *strings.Builder) {
+	if l.ch != '{' {
+		l.errorf(diag.InvalidEscape, start, "invalid unicode escape, expected \\u{...}")
+		return
+	}
+	l.readChar()
+	var digits strings.Builder
+	for isHexDigit(l.ch) {
+		digits.WriteRune(l.ch)
+		l.readChar()
+	}
+	if l.ch != '}' {
+		l.errorf(diag.InvalidEscape, start, "unterminated unicode escape, expected '}'")
+		return
+	}
+	l.readChar()
+	code, err := strconv.ParseUint(digits.String(), 16, 32)
+	if err != nil || digits.Len() > 6 || !utf8.ValidRune(rune(code)) {
+		l.errorf(diag.InvalidEscape, start, "invalid unicode code point \\u{%s}", digits.String())
+		return
+	}
+	out.WriteRune(rune(code))
+}
+
+func isHexDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9' || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+// readComment consumes a `//` comment up to the end of the line or a `/* */`
+// comment, which may nest.
+func (l *Lexer) readComment() token.Token {
+	start := l.pos.Offset
+	l.readChar()
+	if l.ch == '/' {
+		for l.ch != '\n' && l.ch != eof {
+			l.readChar()
+		}
+		return token.Token{Type: token.COMMENT, Literal: l.slice(start, l.pos.Offset)}
+	}
+	l.readChar()
+	for depth := 1; depth > 0; l.readChar() {
+		switch {
+		case l.ch == eof:
+			d := l.errorf(diag.UnterminatedComment, l.tokPos, "unterminated block comment")
+			d.Hint = "close the comment with '*/'"
+			return token.Token{Type: token.COMMENT, Literal: l.slice(start, l.pos.Offset)}
+		case l.ch == '/' && l.peek() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peek() == '/':
+			depth--
+			l.readChar()
+		}
+	}
+	return token.Token{Type: token.COMMENT, Literal: l.slice(start, l.pos.Offset)}
+}
+
 func (l *Lexer) readIdentifier() string {
-	position := l.position
-	for isLetter(l.ch) {
+	start := l.pos.Offset
+	for isLetter(l.ch) || l.kebabCase && l.ch == '-' && isLetter(l.peek()) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	return l.slice(start, l.pos.Offset)
 }
 
 func isLetter(ch rune) bool {
-	return unicode.IsLetter(ch) || ch == '_' || ch == '-'
+	return unicode.IsLetter(ch) || ch == '_'
 }
 
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
 		l.readChar()
+		l.mark = l.pos.Offset
+	}
+}
+
+// readNumber reads an integer or a float. A float has a fraction, which needs
+// a digit after the dot so `1.` stays an INT, an exponent such as `1e-9`, or
+// both. Integers may also be written in hex, octal or binary with a 0x, 0o or
+// 0b prefix, and any literal may group digits with underscores: 1_000_000.
+func (l *Lexer) readNumber() (string, token.TokenType) {
+	start := l.pos.Offset
+	if l.ch == '0' && basePrefixes[l.peek()] != 0 {
+		return l.readPrefixedInteger(start)
+	}
+	tokenType := token.TokenType(token.INT)
+	l.readDigits()
+	if l.ch == '.' && isDigit(l.peek()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+	if l.ch == 'e' || l.ch == 'E' {
+		tokenType = token.FLOAT
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		if !isDigit(l.ch) {
+			d := l.errorf(diag.MalformedNumber, l.tokPos, "exponent has no digits")
+			d.Hint = "write the exponent as in 1e9 or 2.5e-3"
+			tokenType = token.ILLEGAL
+		}
+		l.readDigits()
+	}
+	literal := l.slice(start, l.pos.Offset)
+	if tokenType != token.ILLEGAL && !validSeparators(literal, isDigit) {
+		l.separatorError()
+		tokenType = token.ILLEGAL
 	}
+	if tokenType == token.INT && len(literal) > 1 && literal[0] == '0' {
+		d := l.errorf(diag.MalformedNumber, l.tokPos, "decimal literal has a leading zero")
+		d.Hint = "drop the leading zero, or write an octal literal with the 0o prefix as in 0o755"
+		tokenType = token.ILLEGAL
+	}
+	return literal, tokenType
+}
+
+var basePrefixes = map[rune]int{
+	'x': 16, 'X': 16,
+	'o': 8, 'O': 8,
+	'b': 2, 'B': 2,
+}
+
+var baseNames = map[int]string{
+	16: "hexadecimal",
+	8:  "octal",
+	2:  "binary",
 }
 
-func (l *Lexer) readNumber() string {
-	position := l.position
-	for isDigit(l.ch) {
+// readPrefixedInteger reads a 0x, 0o or 0b literal. It swallows every letter
+// and digit that follows so that `0xZZ` is reported as one bad literal rather
+// than an INT followed by an identifier.
+func (l *Lexer) readPrefixedInteger(start int) (string, token.TokenType) {
+	l.readChar()
+	base := basePrefixes[l.ch]
+	l.readChar()
+	for isLetter(l.ch) || isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	literal := l.slice(start, l.pos.Offset)
+	digits := literal[2:]
+	if strings.Trim(digits, "_") == "" {
+		l.errorf(diag.MalformedNumber, l.tokPos, "%s literal has no digits", baseNames[base])
+		return literal, token.ILLEGAL
+	}
+	for _, ch := range digits {
+		if ch != '_' && digitValue(ch) >= base {
+			l.errorf(diag.MalformedNumber, l.tokPos, "invalid digit %q in %s literal", ch, baseNames[base])
+			return literal, token.ILLEGAL
+		}
+	}
+	if !validSeparators(literal, isHexDigit) {
+		l.separatorError()
+		return literal, token.ILLEGAL
+	}
+	return literal, token.INT
+}
+
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+}
+
+func (l *Lexer) separatorError() {
+	d := l.errorf(diag.MalformedNumber, l.tokPos, "'_' must separate successive digits")
+	d.Hint = "write grouped digits as in 1_000_000"
+}
+
+// validSeparators reports whether every underscore in a number literal sits
+// between two digits, or right after a base prefix as in 0x_FF.
+func validSeparators(literal string, isDigit func(rune) bool) bool {
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '_' {
+			continue
+		}
+		prefix := i == 2 && literal[0] == '0' && basePrefixes[rune(literal[1])] != 0
+		if i == 0 || (!prefix && !isDigit(rune(literal[i-1]))) {
+			return false
+		}
+		if i+1 == len(literal) || !isDigit(rune(literal[i+1])) {
+			return false
+		}
+	}
+	return true
+}
+
+func digitValue(ch rune) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'z':
+		return int(ch-'a') + 10
+	case 'A' <= ch && ch <= 'Z':
+		return int(ch-'A') + 10
+	}
+	return 36
 }
 
 func isDigit(ch rune) bool {
-	return unicode.IsDigit(ch)
+	return '0' <= ch && ch <= '9'
 }
 
 func (l *Lexer) peek() rune {
-	if l.readPosition >= len(l.input) {
-		return 0
-	}
-	return []rune(l.input)[l.readPosition]
+	ch, _ := l.decode(l.pos.Offset + l.width)
+	return ch
 }
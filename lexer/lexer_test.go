@@ -1,7 +1,9 @@
 package lexer
 
 import (
+	"monkey-lang/diag"
 	"monkey-lang/token"
+	"strings"
 	"testing"
 )
 
@@ -13,7 +15,7 @@ func TestNextToken(t *testing.T) {
 		x+y;
 	};
 	let result = add(five, ten);
-	!-/*5;
+	!-/ *5;
 	5 < 10 > 5;
 	if (5 < 10) {
 		return true;
@@ -119,3 +121,533 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 10;\n  x >= 5\n"
+
+	tests := []struct {
+		expectedLiteral string
+		expectedPos     token.Position
+		expectedEnd     token.Position
+	}{
+		{"let", token.Position{Filename: "main.mk", Offset: 0, Line: 1, Column: 1}, token.Position{Filename: "main.mk", Offset: 3, Line: 1, Column: 4}},
+		{"x", token.Position{Filename: "main.mk", Offset: 4, Line: 1, Column: 5}, token.Position{Filename: "main.mk", Offset: 5, Line: 1, Column: 6}},
+		{"=", token.Position{Filename: "main.mk", Offset: 6, Line: 1, Column: 7}, token.Position{Filename: "main.mk", Offset: 7, Line: 1, Column: 8}},
+		{"10", token.Position{Filename: "main.mk", Offset: 8, Line: 1, Column: 9}, token.Position{Filename: "main.mk", Offset: 10, Line: 1, Column: 11}},
+		{";", token.Position{Filename: "main.mk", Offset: 10, Line: 1, Column: 11}, token.Position{Filename: "main.mk", Offset: 11, Line: 1, Column: 12}},
+		{"x", token.Position{Filename: "main.mk", Offset: 14, Line: 2, Column: 3}, token.Position{Filename: "main.mk", Offset: 15, Line: 2, Column: 4}},
+		{">=", token.Position{Filename: "main.mk", Offset: 16, Line: 2, Column: 5}, token.Position{Filename: "main.mk", Offset: 18, Line: 2, Column: 7}},
+		{"5", token.Position{Filename: "main.mk", Offset: 19, Line: 2, Column: 8}, token.Position{Filename: "main.mk", Offset: 20, Line: 2, Column: 9}},
+	}
+
+	l := New(input, WithFilename("main.mk"))
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos != tt.expectedPos {
+			t.Fatalf("tests[%d] - pos wrong. expected=%+v, got=%+v", i, tt.expectedPos, tok.Pos)
+		}
+		if tok.End != tt.expectedEnd {
+			t.Fatalf("tests[%d] - end wrong. expected=%+v, got=%+v", i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestStringTokens(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{`"foobar"`, token.STRING, "foobar"},
+		{`"foo bar"`, token.STRING, "foo bar"},
+		{`""`, token.STRING, ""},
+		{`"a\nb\tc"`, token.STRING, "a\nb\tc"},
+		{`"say \"hi\""`, token.STRING, `say "hi"`},
+		{`"back\\slash"`, token.STRING, `back\slash`},
+		{`"\u{41}\u{1F600}"`, token.STRING, "A\U0001F600"},
+		{`"multi
+line"`, token.STRING, "multi\nline"},
+		{`"unterminated`, token.ILLEGAL, `"unterminated`},
+	}
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Fatalf("tests[%d] - expected EOF after string, got=%q", i, next.Type)
+		}
+	}
+}
+
+func TestLexerDiagnostics(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedCode    diag.Code
+		expectedMessage string
+		expectedStart   int
+		expectedEnd     int
+	}{
+		{`let s = "abc`, diag.UnterminatedString, "unterminated string literal", 8, 12},
+		{`"a\qb"`, diag.InvalidEscape, `unknown escape sequence \q`, 2, 4},
+		{`"\u{110000}"`, diag.InvalidEscape, `invalid unicode code point \u{110000}`, 1, 11},
+		{`"\u{}"`, diag.InvalidEscape, `invalid unicode code point \u{}`, 1, 5},
+		{`"\u{41"`, diag.InvalidEscape, `unterminated unicode escape, expected '}'`, 1, 6},
+		{`"\u41"`, diag.InvalidEscape, `invalid unicode escape, expected \u{...}`, 1, 3},
+		{`x @ y`, diag.IllegalCharacter, `illegal character "@"`, 2, 3},
+	}
+	for i, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+		diagnostics := l.Diagnostics()
+		if len(diagnostics) != 1 {
+			t.Fatalf("tests[%d] - expected 1 diagnostic, got=%d", i, len(diagnostics))
+		}
+		d := diagnostics[0]
+		if d.Code != tt.expectedCode {
+			t.Errorf("tests[%d] - code wrong. expected=%s, got=%s", i, tt.expectedCode, d.Code)
+		}
+		if d.Message != tt.expectedMessage {
+			t.Errorf("tests[%d] - message wrong. expected=%q, got=%q", i, tt.expectedMessage, d.Message)
+		}
+		if d.Span.Start.Offset != tt.expectedStart || d.Span.End.Offset != tt.expectedEnd {
+			t.Errorf("tests[%d] - span wrong. expected=%d-%d, got=%d-%d", i, tt.expectedStart, tt.expectedEnd, d.Span.Start.Offset, d.Span.End.Offset)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+let x = 5; // trailing
+/* block /* nested */ still comment */ x / 2;
+/**/x`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.COMMENT, "// leading comment"},
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.COMMENT, "// trailing"},
+		{token.COMMENT, "/* block /* nested */ still comment */"},
+		{token.IDENT, "x"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.COMMENT, "/**/"},
+		{token.IDENT, "x"},
+		{token.EOF, ""},
+	}
+
+	skipped := New(input)
+	emitted := New(input, WithComments())
+	for i, tt := range tests {
+		tok := emitted.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tt.expectedType == token.COMMENT {
+			continue
+		}
+		tok = skipped.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - comments not skipped. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+	if len(skipped.Diagnostics()) != 0 || len(emitted.Diagnostics()) != 0 {
+		t.Errorf("unexpected diagnostics")
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	l := New("x /* a /* b */")
+	if tok := l.NextToken(); tok.Literal != "x" {
+		t.Fatalf("literal wrong. expected=%q, got=%q", "x", tok.Literal)
+	}
+	if tok := l.NextToken(); tok.Type != token.EOF {
+		t.Fatalf("token type wrong. expected=%q, got=%q", token.EOF, tok.Type)
+	}
+	diagnostics := l.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Code != diag.UnterminatedComment {
+		t.Fatalf("expected one %s diagnostic, got=%v", diag.UnterminatedComment, diagnostics)
+	}
+	if diagnostics[0].Span.Start.Offset != 2 {
+		t.Errorf("span start wrong. expected=2, got=%d", diagnostics[0].Span.Start.Offset)
+	}
+}
+
+func TestNonASCIIIdentifiers(t *testing.T) {
+	input := "let café = \"naïve\";\nlet 日本 = café+ü;"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedOffset  int
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.LET, "let", 0, 1, 1},
+		{token.IDENT, "café", 4, 1, 5},
+		{token.ASSIGN, "=", 10, 1, 10},
+		{token.STRING, "naïve", 12, 1, 12},
+		{token.SEMICOLON, ";", 20, 1, 19},
+		{token.LET, "let", 22, 2, 1},
+		{token.IDENT, "日本", 26, 2, 5},
+		{token.ASSIGN, "=", 33, 2, 8},
+		{token.IDENT, "café", 35, 2, 10},
+		{token.PLUS, "+", 40, 2, 14},
+		{token.IDENT, "ü", 41, 2, 15},
+		{token.SEMICOLON, ";", 43, 2, 16},
+		{token.EOF, "", 44, 2, 17},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos.Offset != tt.expectedOffset || tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - pos wrong. expected=%d %d:%d, got=%d %d:%d", i,
+				tt.expectedOffset, tt.expectedLine, tt.expectedColumn, tok.Pos.Offset, tok.Pos.Line, tok.Pos.Column)
+		}
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	l := New("a \xff b")
+	expected := []token.TokenType{token.IDENT, token.ILLEGAL, token.IDENT, token.EOF}
+	for i, tt := range expected {
+		if tok := l.NextToken(); tok.Type != tt {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+	diagnostics := l.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Message != "invalid UTF-8 encoding" {
+		t.Fatalf("expected one invalid UTF-8 diagnostic, got=%v", diagnostics)
+	}
+}
+
+func benchmarkInput(unit string, size int) string {
+	var b strings.Builder
+	for b.Len() < size {
+		b.WriteString(unit)
+	}
+	return b.String()
+}
+
+func benchmarkLexer(b *testing.B, input string) {
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l := New(input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+	}
+}
+
+func BenchmarkLexerASCII(b *testing.B) {
+	unit := "let add = fn(x, y) { x + y; }; // sum\nlet result = add(five, 10) == \"fifteen\";\n"
+	benchmarkLexer(b, benchmarkInput(unit, 4<<20))
+}
+
+func BenchmarkLexerNonASCII(b *testing.B) {
+	unit := "let café = fn(naïve, 日本) { naïve + 日本; }; /* ü */\nlet résumé = café(1, 2) != \"ñandú\";\n"
+	benchmarkLexer(b, benchmarkInput(unit, 4<<20))
+}
+
+func TestDashInIdentifiers(t *testing.T) {
+	input := "x-1; a-b; max-value - min-value; -x; a--b; a-"
+
+	type expectedToken struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}
+	tests := []struct {
+		opts     []Option
+		expected []expectedToken
+	}{
+		{
+			nil,
+			[]expectedToken{
+				{token.IDENT, "x"}, {token.MINUS, "-"}, {token.INT, "1"}, {token.SEMICOLON, ";"},
+				{token.IDENT, "a"}, {token.MINUS, "-"}, {token.IDENT, "b"}, {token.SEMICOLON, ";"},
+				{token.IDENT, "max"}, {token.MINUS, "-"}, {token.IDENT, "value"}, {token.MINUS, "-"},
+				{token.IDENT, "min"}, {token.MINUS, "-"}, {token.IDENT, "value"}, {token.SEMICOLON, ";"},
+				{token.MINUS, "-"}, {token.IDENT, "x"}, {token.SEMICOLON, ";"},
+				{token.IDENT, "a"}, {token.MINUS, "-"}, {token.MINUS, "-"}, {token.IDENT, "b"}, {token.SEMICOLON, ";"},
+				{token.IDENT, "a"}, {token.MINUS, "-"}, {token.EOF, ""},
+			},
+		},
+		{
+			[]Option{WithKebabCase()},
+			[]expectedToken{
+				{token.IDENT, "x"}, {token.MINUS, "-"}, {token.INT, "1"}, {token.SEMICOLON, ";"},
+				{token.IDENT, "a-b"}, {token.SEMICOLON, ";"},
+				{token.IDENT, "max-value"}, {token.MINUS, "-"}, {token.IDENT, "min-value"}, {token.SEMICOLON, ";"},
+				{token.MINUS, "-"}, {token.IDENT, "x"}, {token.SEMICOLON, ";"},
+				{token.IDENT, "a"}, {token.MINUS, "-"}, {token.MINUS, "-"}, {token.IDENT, "b"}, {token.SEMICOLON, ";"},
+				{token.IDENT, "a"}, {token.MINUS, "-"}, {token.EOF, ""},
+			},
+		},
+	}
+	for i, tt := range tests {
+		l := New(input, tt.opts...)
+		for j, want := range tt.expected {
+			tok := l.NextToken()
+			if tok.Type != want.expectedType || tok.Literal != want.expectedLiteral {
+				t.Fatalf("tests[%d][%d] - token wrong. expected=%q %q, got=%q %q", i, j,
+					want.expectedType, want.expectedLiteral, tok.Type, tok.Literal)
+			}
+		}
+	}
+}
+
+func TestNumberTokens(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{"42", token.INT, "42"},
+		{"3.14", token.FLOAT, "3.14"},
+		{"0.5", token.FLOAT, "0.5"},
+		{"1e9", token.FLOAT, "1e9"},
+		{"1e-9", token.FLOAT, "1e-9"},
+		{"2.5E+3", token.FLOAT, "2.5E+3"},
+		{"1.", token.INT, "1"},
+	}
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestMalformedExponent(t *testing.T) {
+	l := New("1e+;")
+	if tok := l.NextToken(); tok.Type != token.ILLEGAL || tok.Literal != "1e+" {
+		t.Fatalf("token wrong. expected=%q %q, got=%q %q", token.ILLEGAL, "1e+", tok.Type, tok.Literal)
+	}
+	if tok := l.NextToken(); tok.Type != token.SEMICOLON {
+		t.Fatalf("token type wrong. expected=%q, got=%q", token.SEMICOLON, tok.Type)
+	}
+	diagnostics := l.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Code != diag.MalformedNumber {
+		t.Fatalf("expected one %s diagnostic, got=%v", diag.MalformedNumber, diagnostics)
+	}
+}
+
+func TestIntegerLiteralForms(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{"0xFF", token.INT, "0xFF"},
+		{"0Xff", token.INT, "0Xff"},
+		{"0o755", token.INT, "0o755"},
+		{"0b1010", token.INT, "0b1010"},
+		{"1_000_000", token.INT, "1_000_000"},
+		{"0xFF_FF", token.INT, "0xFF_FF"},
+		{"0x_FF", token.INT, "0x_FF"},
+		{"1_000.000_1", token.FLOAT, "1_000.000_1"},
+		{"0", token.INT, "0"},
+		{"0.5", token.FLOAT, "0.5"},
+	}
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if len(l.Diagnostics()) != 0 {
+			t.Fatalf("tests[%d] - unexpected diagnostics %v", i, l.Diagnostics())
+		}
+	}
+}
+
+func TestMalformedIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+		expectedMessage string
+	}{
+		{"0xZZ", "0xZZ", `invalid digit 'Z' in hexadecimal literal`},
+		{"0b102", "0b102", `invalid digit '2' in binary literal`},
+		{"0o78", "0o78", `invalid digit '8' in octal literal`},
+		{"0x", "0x", "hexadecimal literal has no digits"},
+		{"0b_", "0b_", "binary literal has no digits"},
+		{"1_000_", "1_000_", "'_' must separate successive digits"},
+		{"1__0", "1__0", "'_' must separate successive digits"},
+		{"0xF_", "0xF_", "'_' must separate successive digits"},
+		{"1_.5", "1_.5", "'_' must separate successive digits"},
+		{"0755", "0755", "decimal literal has a leading zero"},
+		{"09", "09", "decimal literal has a leading zero"},
+	}
+	for i, tt := range tests {
+		l := New("x = " + tt.input + ";")
+		l.NextToken()
+		l.NextToken()
+		tok := l.NextToken()
+		if tok.Type != token.ILLEGAL || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q", i, token.ILLEGAL, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		diagnostics := l.Diagnostics()
+		if len(diagnostics) != 1 {
+			t.Fatalf("tests[%d] - expected 1 diagnostic, got=%v", i, diagnostics)
+		}
+		d := diagnostics[0]
+		if d.Code != diag.MalformedNumber || d.Message != tt.expectedMessage {
+			t.Errorf("tests[%d] - diagnostic wrong. expected=%q, got=%s %q", i, tt.expectedMessage, d.Code, d.Message)
+		}
+		if d.Span.Start.Column != 5 || d.Span.End.Column != 5+len(tt.expectedLiteral) {
+			t.Errorf("tests[%d] - span wrong. got=%s-%s", i, d.Span.Start, d.Span.End)
+		}
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.AND, "&&"},
+		{token.IDENT, "b"},
+		{token.OR, "||"},
+		{token.BANG, "!"},
+		{token.IDENT, "c"},
+		{token.EOF, ""},
+	}
+	l := New("a && b||!c")
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestArithmeticAndBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "7"},
+		{token.PERCENT, "%"},
+		{token.INT, "2"},
+		{token.POWER, "**"},
+		{token.ASTERISK, "*"},
+		{token.BIT_AND, "&"},
+		{token.AND, "&&"},
+		{token.BIT_OR, "|"},
+		{token.OR, "||"},
+		{token.CARET, "^"},
+		{token.TILDE, "~"},
+		{token.SHL, "<<"},
+		{token.LTE, "<="},
+		{token.LT, "<"},
+		{token.SHR, ">>"},
+		{token.GTE, ">="},
+		{token.GT, ">"},
+		{token.EOF, ""},
+	}
+	l := New("7%2 *** & && | || ^~ << <= < >> >= >")
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestBracketTokens(t *testing.T) {
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RBRACKET, "]"},
+		{token.LBRACKET, "["},
+		{token.INT, "0"},
+		{token.COLON, ":"},
+		{token.RBRACKET, "]"},
+		{token.EOF, ""},
+	}
+	l := New("[1, 2][0:]")
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestAssignmentOperators(t *testing.T) {
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.PLUS_ASSIGN, "+="},
+		{token.MINUS_ASSIGN, "-="},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.SLASH_ASSIGN, "/="},
+		{token.EQ, "=="},
+		{token.POWER, "**"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.EOF, ""},
+	}
+	l := New("x = += -= *= /= == **=1")
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package lexer
+
+import (
+	"io"
+	"monkey-lang/diag"
+)
+
+const chunkSize = 4096
+
+type stream struct {
+	r        io.Reader
+	buf      []byte
+	err      error
+	reported bool
+}
+
+// NewReader returns a lexer that reads its input from r as it goes. Only the
+// token being scanned and the unread part of the last chunk are buffered, so
+// memory stays bounded by the longest token rather than the input size.
+//
+// A read error ends the input: the lexer reports it as a diag.ReadError
+// diagnostic at the position where it happened and then returns EOF.
+func NewReader(r io.Reader, opts ...Option) *Lexer {
+	l := &Lexer{stream: &stream{r: r, buf: make([]byte, chunkSize)}}
+	return l.init(opts)
+}
+
+// Err returns the error that stopped reading, if any. It is nil for lexers
+// over a string and when the reader reached io.EOF.
+func (l *Lexer) Err() error {
+	if l.stream == nil {
+		return nil
+	}
+	return l.stream.err
+}
+
+// fill appends the next chunk from the reader to the buffer, dropping the
+// bytes before mark. It reports false once the reader is exhausted.
+func (l *Lexer) fill() bool {
+	s := l.stream
+	if s.r == nil {
+		return false
+	}
+	n, err := s.r.Read(s.buf)
+	l.input = l.input[l.mark-l.base:] + string(s.buf[:n])
+	l.base = l.mark
+	if err != nil {
+		if err != io.EOF {
+			s.err = err
+		}
+		s.r = nil
+	}
+	return true
+}
+
+func (l *Lexer) reportReadError() {
+	if l.stream == nil || l.stream.err == nil || l.stream.reported {
+		return
+	}
+	l.stream.reported = true
+	l.errorf(diag.ReadError, l.pos, "read error: %v", l.stream.err)
+}
@@ -0,0 +1,114 @@
+package lexer
+
+import (
+	"errors"
+	"io"
+	"monkey-lang/diag"
+	"monkey-lang/token"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNewReaderMatchesNew(t *testing.T) {
+	input := `let café = fn(x, y) { x + y; }; // ü
+	/* block /* nested */ */ let s = "a\tb\u{1F600}";
+	日本 >= 10 != "naïve" @`
+
+	readers := map[string]io.Reader{
+		"whole":    strings.NewReader(input),
+		"one byte": iotest.OneByteReader(strings.NewReader(input)),
+		"half":     iotest.HalfReader(strings.NewReader(input)),
+	}
+	for name, r := range readers {
+		expected := New(input, WithComments())
+		l := NewReader(r, WithComments())
+		for i := 0; ; i++ {
+			want := expected.NextToken()
+			got := l.NextToken()
+			if got != want {
+				t.Fatalf("%s: tokens[%d] differ. expected=%+v, got=%+v", name, i, want, got)
+			}
+			if want.Type == token.EOF {
+				break
+			}
+		}
+		if len(l.Diagnostics()) != len(expected.Diagnostics()) {
+			t.Errorf("%s: diagnostics differ. expected=%v, got=%v", name, expected.Diagnostics(), l.Diagnostics())
+		}
+		if l.Err() != nil {
+			t.Errorf("%s: unexpected read error %v", name, l.Err())
+		}
+	}
+}
+
+func TestNewReaderBoundedMemory(t *testing.T) {
+	unit := "let add = fn(x, y) { x + y; };\n"
+	r := io.LimitReader(&repeatReader{unit: unit}, 8<<20)
+	l := NewReader(r)
+	max := 0
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if len(l.input) > max {
+			max = len(l.input)
+		}
+	}
+	if max > 2*chunkSize {
+		t.Errorf("buffer grew to %d bytes, want at most %d", max, 2*chunkSize)
+	}
+	if l.pos.Offset != 8<<20 {
+		t.Errorf("lexer stopped at offset %d, want %d", l.pos.Offset, 8<<20)
+	}
+}
+
+func TestNewReaderError(t *testing.T) {
+	errBroken := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader("let x = 1;\nlet y"), iotest.ErrReader(errBroken))
+	l := NewReader(r)
+
+	expected := []token.TokenType{token.LET, token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON, token.LET, token.IDENT, token.EOF, token.EOF}
+	for i, tt := range expected {
+		if tok := l.NextToken(); tok.Type != tt {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+	if l.Err() != errBroken {
+		t.Errorf("l.Err() wrong. expected=%v, got=%v", errBroken, l.Err())
+	}
+	diagnostics := l.Diagnostics()
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got=%d", len(diagnostics))
+	}
+	d := diagnostics[0]
+	if d.Code != diag.ReadError || d.Message != "read error: connection reset" {
+		t.Errorf("diagnostic wrong. got=%s %q", d.Code, d.Message)
+	}
+	if d.Span.Start.Line != 2 || d.Span.Start.Column != 6 {
+		t.Errorf("diagnostic position wrong. got=%s", d.Span.Start)
+	}
+}
+
+type repeatReader struct {
+	unit string
+	off  int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		c := copy(p[n:], r.unit[r.off:])
+		n += c
+		r.off = (r.off + c) % len(r.unit)
+	}
+	return n, nil
+}
+
+func BenchmarkLexerReader(b *testing.B) {
+	unit := "let add = fn(x, y) { x + y; }; // sum\nlet result = add(five, 10) == \"fifteen\";\n"
+	input := benchmarkInput(unit, 4<<20)
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		l := NewReader(strings.NewReader(input))
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+	}
+}
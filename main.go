@@ -1,17 +1,38 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"monkey-lang/evaluator"
 	"monkey-lang/repl"
 	"os"
 	"os/user"
 )
 
+var bigInts = flag.Bool("bigint", false, "promote integers to arbitrary precision instead of wrapping on overflow")
+
 func main() {
+	flag.Parse()
+	var opts []evaluator.Option
+	if *bigInts {
+		opts = append(opts, evaluator.WithBigInts())
+	}
+	if flag.NArg() > 0 {
+		path := flag.Arg(0)
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if !repl.Run(path, string(src), os.Stderr, opts...) {
+			os.Exit(1)
+		}
+		return
+	}
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
 	}
 	fmt.Printf("Monkey-Language by MKTP called by %s", user.Username)
-	repl.Start(os.Stdin, os.Stdout)
+	repl.Start(os.Stdin, os.Stdout, opts...)
 }
@@ -0,0 +1,439 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"monkey-lang/ast"
+	"monkey-lang/token"
+	"strconv"
+	"strings"
+)
+
+type (
+	ObjectType string
+
+	Object interface {
+		Type() ObjectType
+		Inspect() string
+	}
+
+	Integer struct {
+		Value int64
+	}
+
+	// BigInteger holds integers outside the int64 range when an interpreter
+	// runs with big integers enabled. It reports the same INTEGER type.
+	BigInteger struct {
+		Value *big.Int
+	}
+
+	Float struct {
+		Value float64
+	}
+
+	Boolean struct {
+		Value bool
+	}
+
+	String struct {
+		Value string
+	}
+
+	Null struct{}
+
+	ReturnValue struct {
+		Value Object
+	}
+
+	// Break and Continue carry a break or continue statement out of the
+	// blocks nested in a loop body up to the loop itself.
+	Break struct{}
+
+	Continue struct{}
+
+	// Error is a runtime error. Span covers the node that failed and Stack
+	// lists the calls that were active, innermost first. Value holds what was
+	// passed to throw and is nil for errors raised by the interpreter.
+	Error struct {
+		Message string
+		Span    token.Span
+		Stack   []Frame
+		Value   Object
+	}
+
+	// Frame is a call to a Monkey function: its name and where it was called.
+	Frame struct {
+		Function string
+		Call     token.Position
+	}
+
+	// Function closes over Env, the environment it was defined in.
+	Function struct {
+		Name       string
+		Parameters []*ast.Identifier
+		Body       *ast.BlockStatement
+		Env        *Environment
+	}
+
+	BuiltinFunction func(args ...Object) Object
+
+	// Builtin is a function implemented in Go. Calls are checked against
+	// MinArgs and MaxArgs before Fn runs; a MaxArgs of -1 means no upper
+	// bound.
+	Builtin struct {
+		Name    string
+		MinArgs int
+		MaxArgs int
+		Fn      BuiltinFunction
+	}
+
+	Array struct {
+		Elements []Object
+	}
+
+	// Range is the integers from Start up to but not including Stop, counting
+	// by Step.
+	Range struct {
+		Start int64
+		Stop  int64
+		Step  int64
+	}
+
+	// Hashable is implemented by objects that can be used as hash keys.
+	Hashable interface {
+		Object
+		HashKey() HashKey
+	}
+
+	HashKey struct {
+		Type  ObjectType
+		Value uint64
+	}
+
+	HashPair struct {
+		Key   Object
+		Value Object
+	}
+
+	// Hash remembers insertion order in Keys so Inspect and iteration are
+	// deterministic.
+	Hash struct {
+		Pairs map[HashKey]HashPair
+		Keys  []HashKey
+	}
+)
+
+const (
+	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
+	BOOLEAN_OBJ      = "BOOLEAN"
+	STRING_OBJ       = "STRING"
+	NULL_OBJ         = "NULL"
+	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	ERROR_OBJ        = "ERROR"
+	FUNCTION_OBJ     = "FUNCTION"
+	BUILTIN_OBJ      = "BUILTIN"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
+	RANGE_OBJ        = "RANGE"
+	ARRAY_OBJ        = "ARRAY"
+	HASH_OBJ         = "HASH"
+)
+
+func (i *Integer) Type() ObjectType {
+	return INTEGER_OBJ
+}
+
+func (i *Integer) Inspect() string {
+	return fmt.Sprintf("%d", i.Value)
+}
+
+func (bi *BigInteger) Type() ObjectType {
+	return INTEGER_OBJ
+}
+
+func (bi *BigInteger) Inspect() string {
+	return bi.Value.String()
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+// Inspect prints floats in plain decimal between 1e-7 and 1e21 and in
+// exponent form outside that range, always with a dot or an exponent so they
+// never read as integers: 3.0, 0.1, 1.5e-09, 1e+21, Inf, NaN.
+func (f *Float) Inspect() string {
+	v := f.Value
+	switch {
+	case math.IsInf(v, 1):
+		return "Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	if abs := math.Abs(v); abs != 0 && (abs < 1e-7 || abs >= 1e21) {
+		return strconv.FormatFloat(v, 'e', -1, 64)
+	}
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+func (b *Boolean) Type() ObjectType {
+	return BOOLEAN_OBJ
+}
+
+func (b *Boolean) Inspect() string {
+	return fmt.Sprintf("%t", b.Value)
+}
+
+func (s *String) Type() ObjectType {
+	return STRING_OBJ
+}
+
+func (s *String) Inspect() string {
+	return s.Value
+}
+
+func (n *Null) Type() ObjectType {
+	return NULL_OBJ
+}
+
+func (n *Null) Inspect() string {
+	return "null"
+}
+
+func (rv *ReturnValue) Type() ObjectType {
+	return RETURN_VALUE_OBJ
+}
+
+func (rv *ReturnValue) Inspect() string {
+	return rv.Value.Inspect()
+}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+
+func (e *Error) Type() ObjectType {
+	return ERROR_OBJ
+}
+
+func (e *Error) Inspect() string {
+	return "ERROR: " + e.Message
+}
+
+// Trace formats the error like a Go panic: the message, then each active
+// function with the position it had reached, innermost first, ending with
+// the top level of the program.
+func (e *Error) Trace() string {
+	var out bytes.Buffer
+	out.WriteString("runtime error: " + e.Message + "\n")
+	e.walkStack(func(function string, pos token.Position) {
+		out.WriteString("\n" + function + "\n")
+		if pos.IsValid() {
+			out.WriteString("\t" + pos.String() + "\n")
+		}
+	})
+	return out.String()
+}
+
+// StackLines returns the frames of Trace one per line, as "function at pos".
+func (e *Error) StackLines() []string {
+	lines := []string{}
+	e.walkStack(func(function string, pos token.Position) {
+		if pos.IsValid() {
+			function += " at " + pos.String()
+		}
+		lines = append(lines, function)
+	})
+	return lines
+}
+
+func (e *Error) walkStack(fn func(function string, pos token.Position)) {
+	pos := e.Span.Start
+	for _, frame := range e.Stack {
+		fn(frame.Function+"(...)", pos)
+		pos = frame.Call
+	}
+	fn("<main>", pos)
+}
+
+func (f *Function) Type() ObjectType {
+	return FUNCTION_OBJ
+}
+
+func (f *Function) Inspect() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(f.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+func (b *Builtin) Type() ObjectType {
+	return BUILTIN_OBJ
+}
+
+func (b *Builtin) Inspect() string {
+	return "builtin function " + b.Name
+}
+
+func (r *Range) Type() ObjectType {
+	return RANGE_OBJ
+}
+
+func (r *Range) Inspect() string {
+	if r.Step == 1 {
+		return fmt.Sprintf("range(%d, %d)", r.Start, r.Stop)
+	}
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.Stop, r.Step)
+}
+
+// Len returns the number of integers in the range. It is computed on
+// unsigned distances so ranges spanning most of int64 do not overflow.
+func (r *Range) Len() int64 {
+	switch {
+	case r.Step > 0 && r.Start < r.Stop:
+		return int64((uint64(r.Stop)-uint64(r.Start)-1)/uint64(r.Step) + 1)
+	case r.Step < 0 && r.Start > r.Stop:
+		return int64((uint64(r.Start)-uint64(r.Stop)-1)/(-uint64(r.Step)) + 1)
+	}
+	return 0
+}
+
+// At returns the i-th integer of the range, for 0 <= i < Len().
+func (r *Range) At(i int64) int64 {
+	return r.Start + i*r.Step
+}
+
+func (a *Array) Type() ObjectType {
+	return ARRAY_OBJ
+}
+
+func (a *Array) Inspect() string {
+	return a.inspect(map[Object]bool{})
+}
+
+func (a *Array) inspect(seen map[Object]bool) string {
+	if seen[a] {
+		return "[...]"
+	}
+	seen[a] = true
+	defer delete(seen, a)
+	elements := []string{}
+	for _, el := range a.Elements {
+		elements = append(elements, inspectNested(el, seen))
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// inspectNested prints an element of a container. Index assignment can make
+// an array or hash contain itself; seen holds the containers being printed,
+// and a repeat is shown as [...] or {...} instead of recursing forever.
+func inspectNested(obj Object, seen map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *Array:
+		return obj.inspect(seen)
+	case *Hash:
+		return obj.inspect(seen)
+	}
+	return obj.Inspect()
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+// bigIntegerKey keeps the hashed keys of big integers apart from Integer keys,
+// which use the raw int64 value and would otherwise collide with them.
+const bigIntegerKey ObjectType = "BIG_INTEGER"
+
+func (bi *BigInteger) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write(bi.Value.Bytes())
+	if bi.Value.Sign() < 0 {
+		h.Write([]byte{'-'})
+	}
+	return HashKey{Type: bigIntegerKey, Value: h.Sum64()}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+// Set stores value under key. A key that is already present keeps its
+// original position.
+func (h *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.Keys = append(h.Keys, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	return pair.Value, ok
+}
+
+func (h *Hash) Type() ObjectType {
+	return HASH_OBJ
+}
+
+func (h *Hash) Inspect() string {
+	return h.inspect(map[Object]bool{})
+}
+
+func (h *Hash) inspect(seen map[Object]bool) string {
+	if seen[h] {
+		return "{...}"
+	}
+	seen[h] = true
+	defer delete(seen, h)
+	pairs := []string{}
+	for _, key := range h.Keys {
+		pair := h.Pairs[key]
+		pairs = append(pairs, pair.Key.Inspect()+": "+inspectNested(pair.Value, seen))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -1,21 +1,28 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"monkey-lang/ast"
+	"monkey-lang/diag"
 	"monkey-lang/lexer"
 	"monkey-lang/token"
+	"sort"
 	"strconv"
 )
 
 type (
 	Parser struct {
 		l              *lexer.Lexer
-		errors         []string
+		diagnostics    []*diag.Diagnostic
 		curToken       token.Token
 		peekToken      token.Token
 		prefixParseFns map[token.TokenType]prefixParseFn
 		infixParseFns  map[token.TokenType]infixParseFn
+		panicking      bool
+		loopDepth      int
+		braceDepth     int
 	}
 
 	prefixParseFn func() ast.Expression
@@ -26,12 +33,21 @@ type (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGNMENT
+	LOGICAL_OR
+	LOGICAL_AND
 	EQUALS
 	LESSGREATER
+	BITWISE_OR
+	BITWISE_XOR
+	BITWISE_AND
+	SHIFT
 	SUM
 	PRODUCT
 	PREFIX
+	POWER
 	CALL
+	INDEX
 )
 
 func New(l *lexer.Lexer) *Parser {
@@ -41,23 +57,46 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.TILDE, p.parsePrefixExpression)
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.pareseInfixExpression)
 	p.registerInfix(token.MINUS, p.pareseInfixExpression)
 	p.registerInfix(token.SLASH, p.pareseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.pareseInfixExpression)
+	p.registerInfix(token.PERCENT, p.pareseInfixExpression)
+	p.registerInfix(token.POWER, p.pareseInfixExpression)
+	p.registerInfix(token.BIT_AND, p.pareseInfixExpression)
+	p.registerInfix(token.BIT_OR, p.pareseInfixExpression)
+	p.registerInfix(token.CARET, p.pareseInfixExpression)
+	p.registerInfix(token.SHL, p.pareseInfixExpression)
+	p.registerInfix(token.SHR, p.pareseInfixExpression)
 	p.registerInfix(token.EQ, p.pareseInfixExpression)
 	p.registerInfix(token.NEQ, p.pareseInfixExpression)
 	p.registerInfix(token.LT, p.pareseInfixExpression)
 	p.registerInfix(token.GT, p.pareseInfixExpression)
 	p.registerInfix(token.LTE, p.pareseInfixExpression)
 	p.registerInfix(token.GTE, p.pareseInfixExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.AND, p.parseLogicalExpression)
+	p.registerInfix(token.OR, p.parseLogicalExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.pareseIfExpression)
+	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
 	return p
 }
@@ -65,6 +104,15 @@ func New(l *lexer.Lexer) *Parser {
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+	for p.peekToken.Type == token.COMMENT {
+		p.peekToken = p.l.NextToken()
+	}
+	switch p.curToken.Type {
+	case token.LBRACE:
+		p.braceDepth++
+	case token.RBRACE:
+		p.braceDepth--
+	}
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
@@ -82,14 +130,64 @@ func (p *Parser) ParseProgram() *ast.Program {
 }
 
 func (p *Parser) parseStament() ast.Statement {
+	start := p.curToken
+	var stm ast.Statement
 	switch p.curToken.Type {
 	case token.LET:
-		return p.parseLetStament()
+		stm = p.parseLetStament()
 	case token.RETURN:
-		return p.parseReturnStament()
+		stm = p.parseReturnStament()
+	case token.THROW:
+		stm = p.parseThrowStatement()
+	case token.TRY:
+		stm = p.parseTryStatement()
+	case token.WHILE:
+		stm = p.parseWhileStatement()
+	case token.FOR:
+		stm = p.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		stm = p.parseLoopControlStatement()
 	default:
-		return p.parseExpressionStatement()
+		stm = p.parseExpressionStatement()
+	}
+	if p.panicking {
+		p.synchronize()
+		return &ast.BadStatement{From: start, To: p.curToken}
+	}
+	return stm
+}
+
+var syncTokens = map[token.TokenType]bool{
+	token.LET:      true,
+	token.RETURN:   true,
+	token.FUNCTION: true,
+	token.IF:       true,
+	token.TRY:      true,
+	token.THROW:    true,
+	token.WHILE:    true,
+	token.FOR:      true,
+	token.RBRACE:   true,
+	token.EOF:      true,
+}
+
+// synchronize skips the rest of a broken statement. It stops on a `;`, or
+// right before the `}` closing the enclosing block or the keyword starting the
+// next statement, skipping over any nested braces on the way.
+func (p *Parser) synchronize() {
+	depth := 0
+	for !p.curTokenIs(token.EOF) {
+		switch {
+		case p.curTokenIs(token.LBRACE):
+			depth++
+		case p.curTokenIs(token.RBRACE) && depth > 0:
+			depth--
+		}
+		if depth == 0 && (p.curTokenIs(token.SEMICOLON) || syncTokens[p.peekToken.Type]) {
+			break
+		}
+		p.nextToken()
 	}
+	p.panicking = false
 }
 
 func (p *Parser) parseLetStament() *ast.LetStatement {
@@ -101,7 +199,12 @@ func (p *Parser) parseLetStament() *ast.LetStatement {
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
-	for !p.curTokenIs(token.SEMICOLON) {
+	p.nextToken()
+	stm.Value = p.parseExpression(LOWEST)
+	if fl, ok := stm.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stm.Name.Value
+	}
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stm
@@ -125,23 +228,192 @@ func (p *Parser) peekTokenIs(tokenType token.TokenType) bool {
 }
 
 func (p *Parser) Errors() []string {
-	return p.errors
+	diagnostics := p.Diagnostics()
+	errors := make([]string, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		errors = append(errors, d.Error())
+	}
+	return errors
+}
+
+// Diagnostics returns the lexer and parser diagnostics in source order.
+func (p *Parser) Diagnostics() []*diag.Diagnostic {
+	diagnostics := append([]*diag.Diagnostic{}, p.l.Diagnostics()...)
+	diagnostics = append(diagnostics, p.diagnostics...)
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Span.Start.Offset < diagnostics[j].Span.Start.Offset
+	})
+	return diagnostics
 }
 
 func (p *Parser) peekErrors(tokenType token.TokenType) {
-	msg := fmt.Sprintf("expect next token to be %s, got %s", tokenType, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.expectErrors(p.peekToken, tokenType)
+}
+
+// expectErrors reports that tok was found where tokenType was expected.
+func (p *Parser) expectErrors(tok token.Token, tokenType token.TokenType) {
+	d := p.errorf(diag.UnexpectedToken, tok, "expect next token to be %s, got %s", tokenType, tok.Type)
+	d.Expected = []token.TokenType{tokenType}
+	switch tokenType {
+	case token.RPAREN, token.RBRACE:
+		d.Hint = fmt.Sprintf("add the missing %q", string(tokenType))
+	case token.IDENT:
+		if p.curTokenIs(token.LET) {
+			d.Hint = "a let statement needs a name: let <name> = <value>;"
+		}
+	}
+}
+
+// errorf records a diagnostic and puts the parser in panic mode. Errors raised
+// while panicking, or repeating the previous one, are follow-on noise and are
+// dropped; the returned diagnostic is still safe to fill in.
+func (p *Parser) errorf(code diag.Code, tok token.Token, format string, a ...interface{}) *diag.Diagnostic {
+	d := &diag.Diagnostic{
+		Severity: diag.Error,
+		Code:     code,
+		Span:     tok.Span(),
+		Message:  fmt.Sprintf(format, a...),
+		Found:    tok.Type,
+	}
+	if p.panicking || p.isDuplicate(d) {
+		return d
+	}
+	p.panicking = true
+	p.diagnostics = append(p.diagnostics, d)
+	return d
+}
+
+func (p *Parser) isDuplicate(d *diag.Diagnostic) bool {
+	if n := len(p.diagnostics); n > 0 {
+		last := p.diagnostics[n-1]
+		return last.Code == d.Code && last.Span.Start == d.Span.Start
+	}
+	return false
 }
 
 func (p *Parser) parseReturnStament() *ast.ReturnStatement {
 	stm := &ast.ReturnStatement{Token: p.curToken}
 	p.nextToken()
-	for !p.curTokenIs(token.SEMICOLON) {
+	stm.ReturnValue = p.parseExpression(LOWEST)
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stm
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stm := &ast.ThrowStatement{Token: p.curToken}
+	p.nextToken()
+	stm.Value = p.parseExpression(LOWEST)
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stm
+}
+
+func (p *Parser) parseTryStatement() *ast.TryStatement {
+	stm := &ast.TryStatement{Token: p.curToken}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stm.Body = p.parseBlockStatement()
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+		if !p.expectPeek(token.LPAREN) || !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stm.CatchParam = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		stm.Catch = p.parseBlockStatement()
+	}
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		stm.Finally = p.parseBlockStatement()
+	}
+	if stm.Catch == nil && stm.Finally == nil {
+		d := p.errorf(diag.UnexpectedToken, p.peekToken, "expect catch or finally after try block, got %s", p.peekToken.Type)
+		d.Expected = []token.TokenType{token.CATCH, token.FINALLY}
+		return nil
+	}
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stm
 }
 
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	stm := &ast.WhileStatement{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	stm.Condition = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	stm.Body = p.parseLoopBody()
+	if stm.Body == nil {
+		return nil
+	}
+	return stm
+}
+
+func (p *Parser) parseForStatement() *ast.ForStatement {
+	stm := &ast.ForStatement{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) || !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stm.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+	p.nextToken()
+	stm.Iterable = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	stm.Body = p.parseLoopBody()
+	if stm.Body == nil {
+		return nil
+	}
+	return stm
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return body
+}
+
+// parseLoopControlStatement parses break and continue, which are only valid
+// inside the body of a loop in the same function.
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	tok := p.curToken
+	if p.loopDepth == 0 {
+		p.errorf(diag.UnexpectedToken, tok, "%s outside loop", tok.Literal)
+		return nil
+	}
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	if tok.Type == token.BREAK {
+		return &ast.BreakStatement{Token: tok}
+	}
+	return &ast.ContinueStatement{Token: tok}
+}
+
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
 	p.prefixParseFns[tokenType] = fn
 }
@@ -162,12 +434,16 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	// defer untrace((trace("parseExpresiion")))
+	start := p.curToken
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
 		p.noPrefixParseFnError(p.curToken.Type)
-		return nil
+		return &ast.BadExpression{From: start, To: p.curToken}
 	}
 	leftExp := prefix()
+	if p.panicking {
+		return &ast.BadExpression{From: start, To: p.curToken}
+	}
 	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
@@ -175,6 +451,9 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		}
 		p.nextToken()
 		leftExp = infix(leftExp)
+		if p.panicking {
+			return &ast.BadExpression{From: start, To: p.curToken}
+		}
 	}
 	return leftExp
 }
@@ -186,17 +465,40 @@ func (p *Parser) parseIdentifier() ast.Expression {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	// defer untrace(trace("parseIntegerLiteral"))
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if err == nil {
+		return &ast.IntegerLiteral{Token: p.curToken, Value: value}
+	}
+	if errors.Is(err, strconv.ErrRange) {
+		if big, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+			return &ast.IntegerLiteral{Token: p.curToken, Big: big}
+		}
+	}
+	p.errorf(diag.InvalidInteger, p.curToken, "could not convert %q as integer", p.curToken.Literal)
+	return nil
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not convert %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorf(diag.InvalidFloat, p.curToken, "could not convert %q as float", p.curToken.Literal)
 		return nil
 	}
-	return &ast.IntegerLiteral{Token: p.curToken, Value: value}
+	return &ast.FloatLiteral{Token: p.curToken, Value: value}
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+// parseIllegal skips a token the lexer already reported, so the statement is
+// dropped without piling a second error on top.
+func (p *Parser) parseIllegal() ast.Expression {
+	p.panicking = true
+	return nil
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.errorf(diag.MissingPrefix, p.curToken, "no prefix parse function for %s found", t)
 }
 
 func (p *Parser) parsePrefixExpression() ast.Expression {
@@ -211,16 +513,40 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 }
 
 var precedences = map[token.TokenType]int{
+	token.ASSIGN:          ASSIGNMENT,
+	token.PLUS_ASSIGN:     ASSIGNMENT,
+	token.MINUS_ASSIGN:    ASSIGNMENT,
+	token.ASTERISK_ASSIGN: ASSIGNMENT,
+	token.SLASH_ASSIGN:    ASSIGNMENT,
+
+	token.OR:       LOGICAL_OR,
+	token.AND:      LOGICAL_AND,
 	token.EQ:       EQUALS,
 	token.NEQ:      EQUALS,
 	token.LT:       LESSGREATER,
 	token.GT:       LESSGREATER,
 	token.LTE:      LESSGREATER,
 	token.GTE:      LESSGREATER,
+	token.BIT_OR:   BITWISE_OR,
+	token.CARET:    BITWISE_XOR,
+	token.BIT_AND:  BITWISE_AND,
+	token.SHL:      SHIFT,
+	token.SHR:      SHIFT,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
+	token.PERCENT:  PRODUCT,
+	token.POWER:    POWER,
+	token.LPAREN:   CALL,
+	token.LBRACKET: INDEX,
+}
+
+// rightAssociative operators parse their right operand one level lower so an
+// operator of the same precedence nests to the right: 2 ** 3 ** 2 is
+// 2 ** (3 ** 2).
+var rightAssociative = map[token.TokenType]bool{
+	token.POWER: true,
 }
 
 func (p *Parser) peekPrecedence() int {
@@ -245,6 +571,40 @@ func (p *Parser) pareseInfixExpression(left ast.Expression) ast.Expression {
 		Left:     left,
 	}
 	precedence := p.curPrecedence()
+	if rightAssociative[p.curToken.Type] {
+		precedence--
+	}
+	p.nextToken()
+	exp.Right = p.parseExpression(precedence)
+	return exp
+}
+
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	exp := &ast.AssignExpression{
+		Token:    p.curToken,
+		Target:   target,
+		Operator: p.curToken.Literal,
+	}
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	default:
+		d := p.errorf(diag.InvalidAssignment, p.curToken, "cannot assign to %s", target)
+		d.Hint = "only names and index expressions such as a[i] can be assigned to"
+		return nil
+	}
+	// Assignment is right-associative: a = b = 1 is a = (b = 1).
+	p.nextToken()
+	exp.Value = p.parseExpression(ASSIGNMENT - 1)
+	return exp
+}
+
+func (p *Parser) parseLogicalExpression(left ast.Expression) ast.Expression {
+	exp := &ast.LogicalExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+	precedence := p.curPrecedence()
 	p.nextToken()
 	exp.Right = p.parseExpression(precedence)
 	return exp
@@ -282,12 +642,27 @@ func (p *Parser) pareseIfExpression() ast.Expression {
 		return nil
 	}
 	exp.Consequence = p.parseBlockStatement()
-	if p.peekTokenIs(token.ELSE) {
+	for p.peekTokenIs(token.ELSE) {
 		p.nextToken()
-		if !p.expectPeek(token.LBRACE) {
+		if !p.peekTokenIs(token.IF) {
+			if !p.expectPeek(token.LBRACE) {
+				return nil
+			}
+			exp.Alternative = p.parseBlockStatement()
+			break
+		}
+		p.nextToken()
+		elseIf := &ast.ElseIf{Token: p.curToken}
+		if !p.expectPeek(token.LPAREN) {
 			return nil
 		}
-		exp.Alternative = p.parseBlockStatement()
+		p.nextToken()
+		elseIf.Condition = p.parseExpression(LOWEST)
+		if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		elseIf.Consequence = p.parseBlockStatement()
+		exp.ElseIfs = append(exp.ElseIfs, elseIf)
 	}
 	return exp
 }
@@ -305,5 +680,155 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		}
 		p.nextToken()
 	}
+	if p.curTokenIs(token.EOF) {
+		p.expectErrors(p.curToken, token.RBRACE)
+	}
+	block.Rbrace = p.curToken
 	return block
 }
+
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	lit.Parameters = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	lit.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
+	return lit
+}
+
+func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return identifiers
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return identifiers
+}
+
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	exp := &ast.CallExpression{Token: p.curToken, Function: function}
+	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	if exp.Arguments == nil {
+		return nil
+	}
+	exp.Rparen = p.curToken
+	return exp
+}
+
+// parseExpressionList parses comma-separated expressions up to and including
+// the end token. It returns nil if the list is not closed properly.
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+	p.nextToken()
+	list = append(list, p.parseExpression(LOWEST))
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(LOWEST))
+	}
+	if !p.expectPeek(end) {
+		return nil
+	}
+	return list
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if array.Elements == nil {
+		return nil
+	}
+	array.Rbracket = p.curToken
+	return array
+}
+
+// parseHashLiteral handles `{` in expression position, which is always a
+// hash. Blocks only appear where the grammar asks for one, after `if`,
+// `else` and a function's parameter list, and are parsed by
+// parseBlockStatement there.
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken, Pairs: []ast.HashPair{}}
+	depth := p.braceDepth
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+		if !p.expectPeek(token.COLON) {
+			return p.skipHashLiteral(depth)
+		}
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return p.skipHashLiteral(depth)
+		}
+	}
+	p.nextToken()
+	hash.Rbrace = p.curToken
+	return hash
+}
+
+// skipHashLiteral moves past the rest of a broken hash literal whose `{`
+// brought the brace depth to depth. Stopping on the hash's own `}` keeps
+// synchronize from taking it for the end of the enclosing block.
+func (p *Parser) skipHashLiteral(depth int) ast.Expression {
+	for p.braceDepth >= depth && !p.curTokenIs(token.EOF) {
+		p.nextToken()
+	}
+	return nil
+}
+
+// parseIndexExpression parses left[index] as well as the slice forms
+// left[low:high], left[low:], left[:high] and left[:].
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	start := p.curToken
+	var low ast.Expression
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		low = p.parseExpression(LOWEST)
+	}
+	if !p.peekTokenIs(token.COLON) {
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return &ast.IndexExpression{Token: start, Left: left, Index: low, Rbracket: p.curToken}
+	}
+	p.nextToken()
+	slice := &ast.SliceExpression{Token: start, Left: left, Low: low}
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		slice.High = p.parseExpression(LOWEST)
+	}
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	slice.Rbracket = p.curToken
+	return slice
+}
@@ -1,10 +1,16 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"monkey-lang/ast"
+	"monkey-lang/diag"
 	"monkey-lang/lexer"
+	"monkey-lang/token"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestLetStatements(t *testing.T) {
@@ -38,6 +44,59 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestLetStatementValues(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+	}{
+		{"let x = 5;", "x", 5},
+		{"let y = true;", "y", true},
+		{"let foobar = y;", "foobar", "y"},
+		{"let x = 5", "x", 5},
+		{"let z = y", "z", "y"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not return 1 statements, returned %d", len(program.Statements))
+		}
+		stm := program.Statements[0]
+		if !testLetStatement(t, stm, tt.expectedIdentifier) {
+			return
+		}
+		val := stm.(*ast.LetStatement).Value
+		if !testLiteralExpression(t, val, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestLetStatementExpressionValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1 + 2;", "let x = (1 + 2);"},
+		{"let x = -a * b", "let x = ((-a) * b);"},
+		{"let x = 1 + 2 let y = x", "let x = (1 + 2);let y = x;"},
+		{"return 1 + 2 * 3", "return (1 + (2 * 3));"},
+		{"return x == y; return z", "return (x == y);return z;"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
 func testLetStatement(t *testing.T, stm ast.Statement, name string) bool {
 	if stm.TokenLiteral() != "let" {
 		t.Errorf("s.TokenLiteral() not return 'let', returned %s", stm.TokenLiteral())
@@ -72,27 +131,34 @@ func checkParseErrors(t *testing.T, p *Parser) {
 }
 
 func TestReturnStatement(t *testing.T) {
-	input := `
-	return 5;
-	return 10;
-	return 993322;
-	`
-	l := lexer.New(input)
-	p := New(l)
-	program := p.ParseProgram()
-	checkParseErrors(t, p)
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not return 3 statements, returned %d", len(program.Statements))
+	tests := []struct {
+		input         string
+		expectedValue interface{}
+	}{
+		{"return 5;", 5},
+		{"return 10;", 10},
+		{"return 993322;", 993322},
+		{"return true;", true},
+		{"return foobar;", "foobar"},
+		{"return 5", 5},
 	}
-	for _, stm := range program.Statements {
-		returnStm, ok := stm.(*ast.ReturnStatement)
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not return 1 statements, returned %d", len(program.Statements))
+		}
+		returnStm, ok := program.Statements[0].(*ast.ReturnStatement)
 		if !ok {
-			t.Errorf("stm not *ast.ReturnStatement, returned %d", stm)
-			continue
+			t.Fatalf("stm not *ast.ReturnStatement, returned %T", program.Statements[0])
 		}
 		if returnStm.TokenLiteral() != "return" {
-			t.Errorf("returnStmt.Name.Value not '%s', returned return", returnStm.TokenLiteral())
-			continue
+			t.Fatalf("returnStm.TokenLiteral() not 'return', returned %s", returnStm.TokenLiteral())
+		}
+		if !testLiteralExpression(t, returnStm.ReturnValue, tt.expectedValue) {
+			return
 		}
 	}
 }
@@ -158,6 +224,7 @@ func TestParsingPrefixExpressions(t *testing.T) {
 		{"-15;", "-", 15},
 		{"!true;", "!", true},
 		{"!false;", "!", false},
+		{"~5;", "~", 5},
 	}
 
 	for _, tt := range prefixTests {
@@ -220,6 +287,13 @@ func TestParsingInfixExpression(t *testing.T) {
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 ** 5;", 5, "**", 5},
+		{"5 & 5;", 5, "&", 5},
+		{"5 | 5;", 5, "|", 5},
+		{"5 ^ 5;", 5, "^", 5},
+		{"5 << 5;", 5, "<<", 5},
+		{"5 >> 5;", 5, ">>", 5},
 		{"true == true", true, "==", true},
 		{"true != false", true, "!=", false},
 		{"false == false", false, "==", false},
@@ -273,14 +347,47 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		{"2 / (5 + 5)", "(2 / (5 + 5))"},
 		{"-(5 + 5)", "(-(5 + 5))"},
 		{"!(true == true)", "(!(true == true))"},
-		// {"a + add(b * c) + d", "((a + add((b * c))) + d)"},
-		// {
-		// 	"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
-		// 	"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
-		// },
-		// {"add(a + b + c * d / f + g)", "add((((a + b) + ((c * d) / f)) + g))"},
-		// {"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
-		// {"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"a + add(b * c) + d", "((a + add((b * c))) + d)"},
+		{
+			"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
+			"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
+		},
+		{"add(a + b + c * d / f + g)", "add((((a + b) + ((c * d) / f)) + g))"},
+		{"-add(x)", "(-add(x))"},
+		{"compose(f, g)(x)", "compose(f, g)(x)"},
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c && d", "((a && b) || (c && d))"},
+		{"a || b || c", "((a || b) || c)"},
+		{"a == b && c != d", "((a == b) && (c != d))"},
+		{"!a && b < c + 1", "((!a) && (b < (c + 1)))"},
+		{"a * b % c", "((a * b) % c)"},
+		{"a + b % c", "(a + (b % c))"},
+		{"2 ** 3 ** 2", "(2 ** (3 ** 2))"},
+		{"-2 ** 2", "(-(2 ** 2))"},
+		{"2 ** -1", "(2 ** (-1))"},
+		{"a * b ** c", "(a * (b ** c))"},
+		{"f(x) ** 2", "(f(x) ** 2)"},
+		{"a | b ^ c & d", "(a | (b ^ (c & d)))"},
+		{"a & b << 1 + c", "(a & (b << (1 + c)))"},
+		{"a >> 1 == b", "((a >> 1) == b)"},
+		{"a & b == c", "((a & b) == c)"},
+		{"a | b && c", "((a | b) && c)"},
+		{"~a & b", "((~a) & b)"},
+		{"~-a", "(~(-a))"},
+		{"x = 1 + 2", "(x = (1 + 2))"},
+		{"x = y = 3", "(x = (y = 3))"},
+		{"x += y * 2", "(x += (y * 2))"},
+		{"a[i] = b || c", "((a[i]) = (b || c))"},
+		{"h[\"k\"] -= 1", "((h[\"k\"]) -= 1)"},
+		{"x *= y /= 2", "(x *= (y /= 2))"},
+		{"f(x = 1)", "f((x = 1))"},
+		{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
+		{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"-a[0]", "(-(a[0]))"},
+		{"a[1:2][0]", "((a[1:2])[0])"},
+		{"f(x)[0]", "(f(x)[0])"},
+		{"a[0](x)", "(a[0])(x)"},
+		{"a[0] ** 2", "((a[0]) ** 2)"},
 	}
 
 	for _, tt := range tests {
@@ -494,3 +601,954 @@ func TestIfElseExpression(t *testing.T) {
 	}
 
 }
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	input := `fn(x, y) { x + y; }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not return 1 statements, returned %d", len(program.Statements))
+	}
+	stm, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	function, ok := stm.Expression.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stm.Expression is not ast.FunctionLiteral. got=%T", stm.Expression)
+	}
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%d", len(function.Parameters))
+	}
+	testLiteralExpression(t, function.Parameters[0], "x")
+	testLiteralExpression(t, function.Parameters[1], "y")
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function.Body.Statements is not 1 statement. got=%d", len(function.Body.Statements))
+	}
+	bodyStm, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("function body stm is not ast.ExpressionStatement. got=%T", function.Body.Statements[0])
+	}
+	testInfixExpression(t, bodyStm.Expression, "x", "+", "y")
+}
+
+func TestFunctionParameterParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+	}{
+		{"fn() {};", []string{}},
+		{"fn(x) {};", []string{"x"}},
+		{"fn(x, y, z) {};", []string{"x", "y", "z"}},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		stm := program.Statements[0].(*ast.ExpressionStatement)
+		function := stm.Expression.(*ast.FunctionLiteral)
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("length parameters wrong. want %d, got=%d", len(tt.expectedParams), len(function.Parameters))
+		}
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+	}
+}
+
+func TestCallExpressionParsing(t *testing.T) {
+	input := "add(1, 2 * 3, 4 + 5);"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not return 1 statements, returned %d", len(program.Statements))
+	}
+	stm, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	exp, ok := stm.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stm.Expression is not ast.CallExpression. got=%T", stm.Expression)
+	}
+	if !testIdentifier(t, exp.Function, "add") {
+		return
+	}
+	if len(exp.Arguments) != 3 {
+		t.Fatalf("wrong length of arguments. got=%d", len(exp.Arguments))
+	}
+	testLiteralExpression(t, exp.Arguments[0], 1)
+	testInfixExpression(t, exp.Arguments[1], 2, "*", 3)
+	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
+}
+
+func TestHigherOrderFunctionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(x, y) { x + y; };", "let add = fn(x, y) (x + y);"},
+		{"let adder = fn(x) { fn(y) { x + y } };", "let adder = fn(x) fn(y) (x + y);"},
+		{"adder(1)(2)", "adder(1)(2)"},
+		{"fn(x) { x }(5)", "fn(x) x(5)"},
+		{"map(fn(x) { x * 2 }, xs)", "map(fn(x) (x * 2), xs)"},
+		{"let r = add(five, ten);", "let r = add(five, ten);"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestParserErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let = 5;", "test.mk:1:5: expect next token to be INDENT, got ="},
+		{"let x 5;", "test.mk:1:7: expect next token to be =, got INT"},
+		{"let x = 5;\nlet y = ;", "test.mk:2:9: no prefix parse function for ; found"},
+		{"fn(x, 1) {}", "test.mk:1:7: expect next token to be INDENT, got INT"},
+		{"add(1, 2", "test.mk:1:9: expect next token to be ), got EOF"},
+		{"09", "test.mk:1:1: decimal literal has a leading zero"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input, lexer.WithFilename("test.mk"))
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestParserDiagnostics(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedCode     diag.Code
+		expectedExpected []token.TokenType
+		expectedFound    token.TokenType
+		expectedLine     int
+		expectedColumn   int
+	}{
+		{"let = 5;", diag.UnexpectedToken, []token.TokenType{token.IDENT}, token.ASSIGN, 1, 5},
+		{"if (x { 1 }", diag.UnexpectedToken, []token.TokenType{token.RPAREN}, token.LBRACE, 1, 7},
+		{"fn() {\n1", diag.UnexpectedToken, []token.TokenType{token.RBRACE}, token.EOF, 2, 2},
+		{"1;\n*2", diag.MissingPrefix, nil, token.ASTERISK, 2, 1},
+		{"09", diag.MalformedNumber, nil, token.ILLEGAL, 1, 1},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		diagnostics := p.Diagnostics()
+		if len(diagnostics) == 0 {
+			t.Fatalf("expected diagnostics for %q, got none", tt.input)
+		}
+		d := diagnostics[0]
+		if d.Code != tt.expectedCode {
+			t.Errorf("wrong code for %q. expected=%s, got=%s", tt.input, tt.expectedCode, d.Code)
+		}
+		if d.Severity != diag.Error {
+			t.Errorf("wrong severity for %q. got=%s", tt.input, d.Severity)
+		}
+		if fmt.Sprint(d.Expected) != fmt.Sprint(tt.expectedExpected) {
+			t.Errorf("wrong expected tokens for %q. expected=%v, got=%v", tt.input, tt.expectedExpected, d.Expected)
+		}
+		if d.Found != tt.expectedFound {
+			t.Errorf("wrong found token for %q. expected=%s, got=%s", tt.input, tt.expectedFound, d.Found)
+		}
+		if d.Span.Start.Line != tt.expectedLine || d.Span.Start.Column != tt.expectedColumn {
+			t.Errorf("wrong position for %q. expected=%d:%d, got=%s", tt.input, tt.expectedLine, tt.expectedColumn, d.Span.Start)
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+		expected       string
+	}{
+		{
+			"let = 5; let y = 10;",
+			[]string{"1:5: expect next token to be INDENT, got ="},
+			"<bad statement>let y = 10;",
+		},
+		{
+			"let x 5 let y = 10",
+			[]string{"1:7: expect next token to be =, got INT"},
+			"<bad statement>let y = 10;",
+		},
+		{
+			"if (x { return 1; } let y = 2;",
+			[]string{"1:7: expect next token to be ), got {"},
+			"<bad statement>let y = 2;",
+		},
+		{
+			"let f = fn(x) { let = 1; x }; f(1)",
+			[]string{"1:21: expect next token to be INDENT, got ="},
+			"let f = fn(x) <bad statement>x;f(1)",
+		},
+		{
+			"let a = * 2; let b = ; c",
+			[]string{
+				"1:9: no prefix parse function for * found",
+				"1:22: no prefix parse function for ; found",
+			},
+			"<bad statement><bad statement>c",
+		},
+		{
+			"add(1, 2\nlet x = 3;",
+			[]string{"2:1: expect next token to be ), got LET"},
+			"<bad statement>let x = 3;",
+		},
+		{
+			"1 + ) + ) + );",
+			[]string{"1:5: no prefix parse function for ) found"},
+			"<bad statement>",
+		},
+		{
+			"let h = {1 2}; let y = 1;",
+			[]string{"1:12: expect next token to be :, got INT"},
+			"<bad statement>let y = 1;",
+		},
+		{
+			"if (true) { 1",
+			[]string{"1:14: expect next token to be }, got EOF"},
+			"<bad statement>",
+		},
+		{
+			"try { 1 } catch (e) { 2",
+			[]string{"1:24: expect next token to be }, got EOF"},
+			"<bad statement>",
+		},
+		{
+			"let f = fn(x) { x + 1",
+			[]string{"1:22: expect next token to be }, got EOF"},
+			"<bad statement>",
+		},
+		{
+			"for (i in [1]) { 1",
+			[]string{"1:19: expect next token to be }, got EOF"},
+			"<bad statement>",
+		},
+		{
+			"while (true) { 1",
+			[]string{"1:17: expect next token to be }, got EOF"},
+			"<bad statement>",
+		},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		errors := p.Errors()
+		if fmt.Sprint(errors) != fmt.Sprint(tt.expectedErrors) {
+			t.Errorf("wrong errors for %q.\nexpected=%q\ngot=%q", tt.input, tt.expectedErrors, errors)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for %q. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestBadStatementSpan(t *testing.T) {
+	p := New(lexer.New("let = 5;\nx"))
+	program := p.ParseProgram()
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not return 2 statements, returned %d", len(program.Statements))
+	}
+	bad, ok := program.Statements[0].(*ast.BadStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.BadStatement. got=%T", program.Statements[0])
+	}
+	if bad.Pos().Offset != 0 || bad.End().Offset != 8 {
+		t.Errorf("bad statement span wrong. got=%d-%d", bad.Pos().Offset, bad.End().Offset)
+	}
+	if !testIdentifier(t, program.Statements[1].(*ast.ExpressionStatement).Expression, "x") {
+		return
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello\tworld";`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	stm := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stm.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral. got=%T", stm.Expression)
+	}
+	if literal.Value != "hello\tworld" {
+		t.Errorf("literal.Value not %q. got=%q", "hello\tworld", literal.Value)
+	}
+	if literal.String() != `"hello\tworld"` {
+		t.Errorf("literal.String() not %q. got=%q", `"hello\tworld"`, literal.String())
+	}
+}
+
+func TestLexerErrorsReported(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{`let s = "abc`, []string{"1:9: unterminated string literal"}},
+		{"let a = 1 @ 2; let b = 2;", []string{`1:11: illegal character "@"`}},
+		{"let a = \"\\q\"; let = 1;", []string{`1:10: unknown escape sequence \q`, "1:19: expect next token to be INDENT, got ="}},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if fmt.Sprint(p.Errors()) != fmt.Sprint(tt.expectedErrors) {
+			t.Errorf("wrong errors for %q.\nexpected=%q\ngot=%q", tt.input, tt.expectedErrors, p.Errors())
+		}
+	}
+}
+
+func TestParsingWithComments(t *testing.T) {
+	input := `
+	// add two numbers
+	let add = fn(x, /* first */ y) {
+		x + y; // sum
+	};
+	add(1, 2) /* done */
+	`
+	expected := "let add = fn(x, y) (x + y);add(1, 2)"
+	for _, l := range []*lexer.Lexer{lexer.New(input), lexer.New(input, lexer.WithComments())} {
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if program.String() != expected {
+			t.Errorf("expected=%q, got=%q", expected, program.String())
+		}
+	}
+}
+
+func TestParsingFromReader(t *testing.T) {
+	input := "let add = fn(x, y) { x + y; };\nadd(1, 2 * 3)"
+	p := New(lexer.NewReader(iotest.OneByteReader(strings.NewReader(input))))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	expected := "let add = fn(x, y) (x + y);add(1, (2 * 3))"
+	if program.String() != expected {
+		t.Errorf("expected=%q, got=%q", expected, program.String())
+	}
+
+	p = New(lexer.NewReader(io.MultiReader(strings.NewReader("let x = 1;"), iotest.ErrReader(errors.New("boom")))))
+	p.ParseProgram()
+	errs := p.Errors()
+	if len(errs) != 1 || errs[0] != "1:11: read error: boom" {
+		t.Errorf("wrong errors. got=%q", errs)
+	}
+}
+
+func TestDashParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		opts     []lexer.Option
+		expected string
+	}{
+		{"a-b", nil, "(a - b)"},
+		{"x-1*y", nil, "(x - (1 * y))"},
+		{"let max-value = 10; max-value - 1", []lexer.Option{lexer.WithKebabCase()}, "let max-value = 10;(max-value - 1)"},
+		{"a-b-1", []lexer.Option{lexer.WithKebabCase()}, "(a-b - 1)"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input, tt.opts...))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"1e-9", 1e-9},
+		{"2.5e3", 2500},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		stm := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stm.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral. got=%T", stm.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+		if literal.String() != strings.TrimSuffix(tt.input, ";") {
+			t.Errorf("literal.String() not %q. got=%q", tt.input, literal.String())
+		}
+	}
+
+	p := New(lexer.New("-1.5 * 2"))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	if program.String() != "((-1.5) * 2)" {
+		t.Errorf("expected=%q, got=%q", "((-1.5) * 2)", program.String())
+	}
+
+	p = New(lexer.New("1e999"))
+	p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 1 || errs[0] != `1:1: could not convert "1e999" as float` {
+		t.Errorf("wrong errors. got=%q", errs)
+	}
+}
+
+func TestIntegerLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xFF", 255},
+		{"0o755", 493},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"0xdead_beef", 0xdeadbeef},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		stm := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stm.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stm.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %d. got=%d", tt.expected, literal.Value)
+		}
+		if literal.String() != tt.input {
+			t.Errorf("literal.String() not %q. got=%q", tt.input, literal.String())
+		}
+	}
+
+	p := New(lexer.New("let x = 0xZZ + 1;\nlet y = 1_;"))
+	p.ParseProgram()
+	expected := []string{
+		"1:9: invalid digit 'Z' in hexadecimal literal",
+		"2:9: '_' must separate successive digits",
+	}
+	if fmt.Sprint(p.Errors()) != fmt.Sprint(expected) {
+		t.Errorf("wrong errors.\nexpected=%q\ngot=%q", expected, p.Errors())
+	}
+}
+
+func TestHugeIntegerLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"99999999999999999999", "99999999999999999999"},
+		{"0xFFFF_FFFF_FFFF_FFFF_FF", "4722366482869645213695"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		stm := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stm.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stm.Expression)
+		}
+		if literal.Big == nil || literal.Big.String() != tt.expected {
+			t.Errorf("literal.Big not %s. got=%v", tt.expected, literal.Big)
+		}
+		if literal.String() != tt.input {
+			t.Errorf("literal.String() not %q. got=%q", tt.input, literal.String())
+		}
+	}
+}
+
+func TestLogicalExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		left     interface{}
+		operator string
+		right    interface{}
+	}{
+		{"a && b", "a", "&&", "b"},
+		{"true || false", true, "||", false},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		stm := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stm.Expression.(*ast.LogicalExpression)
+		if !ok {
+			t.Fatalf("exp not *ast.LogicalExpression. got=%T", stm.Expression)
+		}
+		if !testLiteralExpression(t, exp.Left, tt.left) {
+			return
+		}
+		if exp.Operator != tt.operator {
+			t.Errorf("exp.Operator is not %s. got=%s", tt.operator, exp.Operator)
+		}
+		if !testLiteralExpression(t, exp.Right, tt.right) {
+			return
+		}
+	}
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	p := New(lexer.New("[1, 2 * 2, 3 + 3]"))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	stm := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stm.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.ArrayLiteral. got=%T", stm.Expression)
+	}
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) not 3. got=%d", len(array.Elements))
+	}
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+	if array.End().Offset != 17 {
+		t.Errorf("array.End() wrong. expected offset 17, got=%d", array.End().Offset)
+	}
+
+	p = New(lexer.New("[]"))
+	program = p.ParseProgram()
+	checkParseErrors(t, p)
+	empty := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ArrayLiteral)
+	if len(empty.Elements) != 0 {
+		t.Errorf("len(empty.Elements) not 0. got=%d", len(empty.Elements))
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	p := New(lexer.New("myArray[1 + 1]"))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	stm := program.Statements[0].(*ast.ExpressionStatement)
+	index, ok := stm.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexExpression. got=%T", stm.Expression)
+	}
+	testIdentifier(t, index.Left, "myArray")
+	testInfixExpression(t, index.Index, 1, "+", 1)
+}
+
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		low      interface{}
+		high     interface{}
+		expected string
+	}{
+		{"a[1:3]", 1, 3, "(a[1:3])"},
+		{"a[1:]", 1, nil, "(a[1:])"},
+		{"a[:-1]", nil, nil, "(a[:(-1)])"},
+		{"a[:]", nil, nil, "(a[:])"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		stm := program.Statements[0].(*ast.ExpressionStatement)
+		slice, ok := stm.Expression.(*ast.SliceExpression)
+		if !ok {
+			t.Fatalf("exp not *ast.SliceExpression. got=%T", stm.Expression)
+		}
+		testIdentifier(t, slice.Left, "a")
+		if tt.low != nil {
+			testLiteralExpression(t, slice.Low, tt.low)
+		}
+		if tt.high != nil {
+			testLiteralExpression(t, slice.High, tt.high)
+		}
+		if slice.String() != tt.expected {
+			t.Errorf("slice.String() wrong. expected=%q, got=%q", tt.expected, slice.String())
+		}
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"one": 1, "two": 2, "three": 3}`, `{"one": 1, "two": 2, "three": 3}`},
+		{"{}", "{}"},
+		{`{"one": 0 + 1, "two": 10 - 8, "three": 15 / 5}`, `{"one": (0 + 1), "two": (10 - 8), "three": (15 / 5)}`},
+		{`{1: true, true: "x", "k": [1]}`, `{1: true, true: "x", "k": [1]}`},
+		{`{"a": {"b": 1}}["a"]`, `({"a": {"b": 1}}["a"])`},
+		{`if (x) { {"a": 1} }`, `ifx {"a": 1}`},
+		{`let h = {"a": 1,};`, `let h = {"a": 1};`},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New(`{"one": 1, "two": 2}`))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	stm := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stm.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stm.Expression)
+	}
+	expected := []struct {
+		key   string
+		value int64
+	}{{"one", 1}, {"two", 2}}
+	if len(hash.Pairs) != len(expected) {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+	for i, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("key is not ast.StringLiteral. got=%T", pair.Key)
+			continue
+		}
+		if literal.Value != expected[i].key {
+			t.Errorf("key wrong. expected=%q, got=%q", expected[i].key, literal.Value)
+		}
+		testIntegerLiteral(t, pair.Value, expected[i].value)
+	}
+}
+
+func TestHashLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`{"a" 1}`, "1:6: expect next token to be :, got INT"},
+		{`{"a": 1 "b": 2}`, "1:9: expect next token to be ,, got STRING"},
+		{"let h = {1 2}; let y = 1;", "1:12: expect next token to be :, got INT"},
+		{`{"a": 1, "b" 2}`, "1:14: expect next token to be :, got INT"},
+		{"{1: 2,, 3: 4}", "1:7: no prefix parse function for , found"},
+		{"{1: {2 3}, 4: 5}", "1:8: expect next token to be :, got INT"},
+		{"{1: fn(x) { x } 3}; 1", "1:17: expect next token to be ,, got INT"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected 1 parser error for %q, got=%q", tt.input, errors)
+			continue
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestFunctionLiteralWithName(t *testing.T) {
+	p := New(lexer.New("let myFunction = fn() { }; let other = fn() { fn() { } };"))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	expected := []string{"myFunction", "other"}
+	for i, name := range expected {
+		stm := program.Statements[i].(*ast.LetStatement)
+		function, ok := stm.Value.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("stm.Value is not ast.FunctionLiteral. got=%T", stm.Value)
+		}
+		if function.Name != name {
+			t.Errorf("function literal name wrong. want %q, got=%q", name, function.Name)
+		}
+	}
+	inner := program.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body.Statements[0]
+	if name := inner.(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral).Name; name != "" {
+		t.Errorf("inner function literal should be anonymous. got=%q", name)
+	}
+}
+
+func TestTryStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		param    string
+		catch    bool
+		finally  bool
+	}{
+		{"try { x } catch (e) { y }", "try { x } catch (e) { y }", "e", true, false},
+		{"try { x } finally { z }", "try { x } finally { z }", "", false, true},
+		{"try { x; y } catch (err) { err } finally { z }", "try { xy } catch (err) { err } finally { z }", "err", true, true},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		stm, ok := program.Statements[0].(*ast.TryStatement)
+		if !ok {
+			t.Fatalf("stm is not ast.TryStatement. got=%T", program.Statements[0])
+		}
+		if stm.String() != tt.expected {
+			t.Errorf("String() wrong. expected=%q, got=%q", tt.expected, stm.String())
+		}
+		if (stm.Catch != nil) != tt.catch || (stm.Finally != nil) != tt.finally {
+			t.Errorf("blocks wrong for %q. catch=%v finally=%v", tt.input, stm.Catch != nil, stm.Finally != nil)
+		}
+		if tt.catch {
+			testIdentifier(t, stm.CatchParam, tt.param)
+		}
+	}
+}
+
+func TestThrowStatementParsing(t *testing.T) {
+	p := New(lexer.New(`throw "boom"; throw {"message": x}`))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	expected := []string{`throw "boom";`, `throw {"message": x};`}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d", len(expected), len(program.Statements))
+	}
+	for i, want := range expected {
+		stm, ok := program.Statements[i].(*ast.ThrowStatement)
+		if !ok {
+			t.Fatalf("stm is not ast.ThrowStatement. got=%T", program.Statements[i])
+		}
+		if stm.String() != want {
+			t.Errorf("String() wrong. expected=%q, got=%q", want, stm.String())
+		}
+	}
+}
+
+func TestTryStatementErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"try { x } let y = 1;", "1:11: expect catch or finally after try block, got LET"},
+		{"try { x } catch e { }", "1:17: expect next token to be (, got INDENT"},
+		{"try { x } catch () { }", "1:18: expect next token to be INDENT, got )"},
+		{"try x", "1:5: expect next token to be {, got INDENT"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tt.input)
+			continue
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestLoopParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (x < 10) { x }", "while ((x < 10)) { x }"},
+		{"while (true) { break; }", "while (true) { break; }"},
+		{"for (x in [1, 2]) { puts(x) }", "for (x in [1, 2]) { puts(x) }"},
+		{"for (k in h) { if (k) { continue } }", "for (k in h) { ifk continue; }"},
+		{"for (i in range(3)) { while (i) { break } }", "for (i in range(3)) { while (i) { break; } }"},
+		{"while (a) { }; 1", "while (a) {  }1"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New("for (item in items) { item }"))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	stm, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("stm is not ast.ForStatement. got=%T", program.Statements[0])
+	}
+	testIdentifier(t, stm.Variable, "item")
+	testIdentifier(t, stm.Iterable, "items")
+	if len(stm.Body.Statements) != 1 {
+		t.Errorf("body has wrong number of statements. got=%d", len(stm.Body.Statements))
+	}
+}
+
+func TestLoopParsingErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"break;", "1:1: break outside loop"},
+		{"if (x) { continue }", "1:10: continue outside loop"},
+		{"while (x) { fn() { break } }", "1:20: break outside loop"},
+		{"for (x of y) { }", "1:8: expect next token to be IN, got INDENT"},
+		{"for (1 in y) { }", "1:6: expect next token to be INDENT, got INT"},
+		{"while x { }", "1:7: expect next token to be (, got INDENT"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tt.input)
+			continue
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator string
+	}{
+		{"x = 5;", "="},
+		{"x += 5;", "+="},
+		{"x -= 5;", "-="},
+		{"x *= 5;", "*="},
+		{"x /= 5;", "/="},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		stm := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stm.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("exp not *ast.AssignExpression. got=%T", stm.Expression)
+		}
+		testIdentifier(t, exp.Target, "x")
+		if exp.Operator != tt.operator {
+			t.Errorf("exp.Operator is not %s. got=%s", tt.operator, exp.Operator)
+		}
+		testIntegerLiteral(t, exp.Value, 5)
+	}
+}
+
+func TestInvalidAssignmentTargets(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"1 = 2", "1:3: cannot assign to 1"},
+		{"f() = 2", "1:5: cannot assign to f()"},
+		{"a + b = 3", "1:7: cannot assign to (a + b)"},
+		{"a[0:1] = [1]", "1:8: cannot assign to (a[0:1])"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		diagnostics := p.Diagnostics()
+		if len(diagnostics) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tt.input)
+			continue
+		}
+		if diagnostics[0].Error() != tt.expectedError {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, diagnostics[0].Error())
+		}
+		if diagnostics[0].Code != diag.InvalidAssignment {
+			t.Errorf("wrong code for %q. expected=%s, got=%s", tt.input, diag.InvalidAssignment, diagnostics[0].Code)
+		}
+	}
+}
+
+func TestElseIfChain(t *testing.T) {
+	input := `if (x < 0) { a } else if (x == 0) { b } else if (x < 10) { c } else { d }`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	exp, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("exp is not ast.IfExpression. got=%T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	testInfixExpression(t, exp.Condition, "x", "<", 0)
+	if len(exp.ElseIfs) != 2 {
+		t.Fatalf("exp.ElseIfs does not contain 2 branches. got=%d", len(exp.ElseIfs))
+	}
+	testInfixExpression(t, exp.ElseIfs[0].Condition, "x", "==", 0)
+	testInfixExpression(t, exp.ElseIfs[1].Condition, "x", "<", 10)
+	expectedBodies := []string{"b", "c"}
+	for i, body := range expectedBodies {
+		consequence := exp.ElseIfs[i].Consequence.Statements[0].(*ast.ExpressionStatement)
+		testIdentifier(t, consequence.Expression, body)
+	}
+	if exp.Alternative == nil {
+		t.Fatalf("exp.Alternative is nil")
+	}
+	testIdentifier(t, exp.Alternative.Statements[0].(*ast.ExpressionStatement).Expression, "d")
+	if exp.End().Offset != len(input) {
+		t.Errorf("exp.End() wrong. expected offset %d, got=%d", len(input), exp.End().Offset)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input, "if(x < 0) aelse if(x == 0) belse if(x < 10) celsed"},
+		{"if (a) { 1 } else if (b) { 2 }", "ifa 1else ifb 2"},
+		{"if (a) { 1 } else { if (b) { 2 } }", "ifa 1elseifb 2"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestElseIfErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"if (a) { 1 } else if b { 2 }", "1:22: expect next token to be (, got INDENT"},
+		{"if (a) { 1 } else if (b) 2", "1:26: expect next token to be {, got INT"},
+		{"if (a) { 1 } else 2", "1:19: expect next token to be {, got INT"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tt.input)
+			continue
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
@@ -4,14 +4,19 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"monkey-lang/diag"
+	"monkey-lang/evaluator"
 	"monkey-lang/lexer"
-	"monkey-lang/token"
+	"monkey-lang/object"
+	"monkey-lang/parser"
 )
 
 const PROMT = ">> "
 
-func Start(input io.Reader, output io.Writer) {
+func Start(input io.Reader, output io.Writer, opts ...evaluator.Option) {
+	interpreter := evaluator.New(append([]evaluator.Option{evaluator.WithOutput(output)}, opts...)...)
 	scanner := bufio.NewScanner(input)
+	env := object.NewEnvironment()
 	for {
 		fmt.Fprint(output, PROMT)
 		scanned := scanner.Scan()
@@ -19,9 +24,40 @@ func Start(input io.Reader, output io.Writer) {
 			return
 		}
 		line := scanner.Text()
-		l := lexer.New(line)
-		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
-			fmt.Fprintf(output, "%+v\n", tok)
+		l := lexer.New(line, lexer.WithFilename("<stdin>"))
+		p := parser.New(l)
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			printParserErrors(output, line, p.Diagnostics())
+			continue
 		}
+		evaluated := interpreter.Eval(program, env)
+		if err, ok := evaluated.(*object.Error); ok {
+			fmt.Fprint(output, err.Trace())
+		} else if evaluated != nil {
+			fmt.Fprintln(output, evaluated.Inspect())
+		}
+	}
+}
+
+func Run(filename, input string, output io.Writer, opts ...evaluator.Option) bool {
+	l := lexer.New(input, lexer.WithFilename(filename))
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(output, input, p.Diagnostics())
+		return false
+	}
+	evaluated := evaluator.New(opts...).Eval(program, object.NewEnvironment())
+	if err, ok := evaluated.(*object.Error); ok {
+		fmt.Fprint(output, err.Trace())
+		return false
+	}
+	return true
+}
+
+func printParserErrors(output io.Writer, src string, diagnostics []*diag.Diagnostic) {
+	for _, d := range diagnostics {
+		diag.Render(output, src, d)
 	}
 }